@@ -5,6 +5,7 @@ package wrpslog
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"testing"
 
@@ -26,8 +27,11 @@ func BenchmarkObserver_ObserveWRP(b *testing.B) {
 	}
 
 	tests := []struct {
-		name   string
-		fields []FieldOpt
+		name    string
+		fields  []FieldOpt
+		group   string
+		sampler Sampler
+		limiter *Limiter
 	}{
 		{
 			name:   "single_field",
@@ -54,6 +58,30 @@ func BenchmarkObserver_ObserveWRP(b *testing.B) {
 				URL(), PartnerIDs(), SessionID(), QualityOfService(),
 			},
 		},
+		{
+			name: "custom_field",
+			fields: []FieldOpt{
+				Source(),
+				Field("payload_kind", func(msg wrp.Message) slog.Attr {
+					return slog.String("", msg.ContentType)
+				}),
+			},
+		},
+		{
+			name:   "six_fields_grouped",
+			fields: []FieldOpt{Source(), Destination(), MessageType(), TransactionUUID(), ContentType(), PayloadSize()},
+			group:  "wrp",
+		},
+		{
+			name:    "three_fields_sampled",
+			fields:  []FieldOpt{Source(), Destination(), MessageType()},
+			sampler: OneInN(10),
+		},
+		{
+			name:    "three_fields_limited",
+			fields:  []FieldOpt{Source(), Destination(), MessageType()},
+			limiter: &Limiter{Rate: 1000, By: LimitByType},
+		},
 	}
 
 	for _, tt := range tests {
@@ -63,6 +91,9 @@ func BenchmarkObserver_ObserveWRP(b *testing.B) {
 				Level:   slog.LevelInfo,
 				Message: "wrp message",
 				Fields:  tt.fields,
+				Group:   tt.group,
+				Sampler: tt.sampler,
+				Limiter: tt.limiter,
 			}
 			ctx := context.Background()
 
@@ -150,3 +181,62 @@ func BenchmarkObserver_ObserveWRP_EmptyFields(b *testing.B) {
 		ob.ObserveWRP(ctx, msg)
 	}
 }
+
+func BenchmarkObserver_ObserveWRP_HeadersMetadata(b *testing.B) {
+	msg := wrp.Message{
+		Type:    wrp.SimpleEventMessageType,
+		Source:  "mac:112233445566",
+		Headers: []string{"X-Midt-Version: 1", "X-Midt-Trace: abc123", "X-Midt-Partner: comcast"},
+		Metadata: map[string]string{
+			"hw-model":               "XB7",
+			"fw-name":                "fw-1.2.3",
+			"/boot-time":             "1700000000",
+			"/last-reconnect-reason": "wan_restart",
+		},
+	}
+
+	// any renders the fields the way slog.Any does for the raw slice and map,
+	// which is how they were logged before being rendered as groups.
+	anyFields := []FieldOpt{
+		Field(fHeaders, func(msg wrp.Message) slog.Attr {
+			return slog.Any("", msg.Headers)
+		}),
+		Field(fMetadata, func(msg wrp.Message) slog.Attr {
+			return slog.Any("", msg.Metadata)
+		}),
+	}
+	groupFields := []FieldOpt{Headers(), Metadata()}
+
+	handlers := []struct {
+		name    string
+		handler slog.Handler
+	}{
+		{name: "text", handler: slog.NewTextHandler(io.Discard, nil)},
+		{name: "json", handler: slog.NewJSONHandler(io.Discard, nil)},
+	}
+
+	for _, h := range handlers {
+		for _, fields := range []struct {
+			name   string
+			fields []FieldOpt
+		}{
+			{name: "any", fields: anyFields},
+			{name: "group", fields: groupFields},
+		} {
+			b.Run(h.name+"_"+fields.name, func(b *testing.B) {
+				ob := Observer{
+					Logger:  slog.New(h.handler),
+					Level:   slog.LevelInfo,
+					Message: "wrp message",
+					Fields:  fields.fields,
+				}
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				for b.Loop() {
+					ob.ObserveWRP(ctx, msg)
+				}
+			})
+		}
+	}
+}
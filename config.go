@@ -0,0 +1,336 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redaction modes accepted by Config.
+const (
+	RedactionNone = ""
+	RedactionMask = "mask"
+	RedactionHMAC = "hmac"
+)
+
+// Rate limit modes accepted by Config.
+const (
+	LimitAll    = ""
+	LimitDevice = "device"
+	LimitType   = "type"
+)
+
+// Sampling modes accepted by Config.
+const (
+	SampleRandom      = ""
+	SampleTransaction = "transaction"
+	SampleDevice      = "device"
+)
+
+// DefaultMessage is the log message used when Config.Message is empty.
+const DefaultMessage = "wrp message"
+
+// ErrInvalidConfig is returned when a Config cannot be used to build an
+// Observer.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config describes an Observer in a form that can be loaded from JSON, YAML
+// or text based configuration.
+//
+// A JSON (or YAML) document looks like:
+//
+//	{
+//	  "level": "debug",
+//	  "message": "wrp message",
+//	  "fields": "msg_type:string,source,dest,transaction_uuid",
+//	  "redaction": "hmac",
+//	  "redaction_key": "secret",
+//	  "escalate": true,
+//	  "key_names": {"dest": "wrp.destination"},
+//	  "group": "wrp",
+//	  "sampling": {"fraction": 0.01, "by": "transaction", "seed": 42},
+//	  "rate_limit": {"rate": 100, "by": "device"},
+//	  "dedupe": {"window": "5s"}
+//	}
+type Config struct {
+	// Level is the level messages are logged at, such as "debug" or "warn".
+	// Defaults to info.
+	Level slog.Level `json:"level" yaml:"level"`
+
+	// Message is the log message text. Defaults to DefaultMessage.
+	Message string `json:"message" yaml:"message"`
+
+	// Fields is the field spec parsed by ParseFields.
+	Fields FieldSpec `json:"fields" yaml:"fields"`
+
+	// Redaction selects how device identifiers are redacted: RedactionNone,
+	// RedactionMask or RedactionHMAC.
+	Redaction string `json:"redaction" yaml:"redaction"`
+
+	// RedactionKey is the key used by RedactionHMAC.
+	RedactionKey string `json:"redaction_key" yaml:"redaction_key"`
+
+	// Escalate enables DefaultEscalation.
+	Escalate bool `json:"escalate" yaml:"escalate"`
+
+	// KeyNames renames the keys of built-in fields. See Observer.KeyNames.
+	KeyNames map[string]string `json:"key_names" yaml:"key_names"`
+
+	// KeyPrefix is prepended to every field key. See Observer.KeyPrefix.
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+
+	// Group is the key of a group that all fields are logged in. See
+	// Observer.Group.
+	Group string `json:"group" yaml:"group"`
+
+	// Sampling configures the Sampler. By default every message is logged.
+	Sampling Sampling `json:"sampling" yaml:"sampling"`
+
+	// RateLimit configures the Limiter. By default the rate is not limited.
+	RateLimit RateLimit `json:"rate_limit" yaml:"rate_limit"`
+
+	// Dedupe configures the Deduper. By default repeats are logged.
+	Dedupe Dedupe `json:"dedupe" yaml:"dedupe"`
+}
+
+// Sampling describes the Sampler of an Observer built from a Config.
+type Sampling struct {
+	// Fraction is the fraction of messages logged, between 0 and 1. Zero
+	// disables sampling.
+	Fraction float64 `json:"fraction" yaml:"fraction"`
+
+	// By selects what messages are sampled by: SampleRandom, SampleTransaction
+	// or SampleDevice. See ConsistentSampler.
+	By string `json:"by" yaml:"by"`
+
+	// Seed is the seed of consistent sampling. Processes that share a seed
+	// sample the same transactions or devices.
+	Seed uint64 `json:"seed" yaml:"seed"`
+}
+
+// RateLimit describes the Limiter of an Observer built from a Config.
+type RateLimit struct {
+	// Rate is the number of messages per second logged. Zero disables rate
+	// limiting.
+	Rate float64 `json:"rate" yaml:"rate"`
+
+	// Burst is the number of messages logged at once. See Limiter.Burst.
+	Burst int `json:"burst" yaml:"burst"`
+
+	// By selects what the rate applies to: LimitAll, LimitDevice or
+	// LimitType.
+	By string `json:"by" yaml:"by"`
+}
+
+// limiter returns the Limiter described by r, or nil if the rate is not
+// limited.
+func (r RateLimit) limiter() *Limiter {
+	if r.Rate == 0 {
+		return nil
+	}
+
+	l := Limiter{Rate: r.Rate, Burst: r.Burst}
+	switch r.By {
+	case LimitDevice:
+		l.By = LimitByDevice
+	case LimitType:
+		l.By = LimitByType
+	}
+	return &l
+}
+
+// Dedupe describes the Deduper of an Observer built from a Config.
+type Dedupe struct {
+	// Window is how long repeats of a message are suppressed for, as
+	// accepted by time.ParseDuration, such as "5s". Empty disables
+	// deduplication.
+	Window string `json:"window" yaml:"window"`
+}
+
+// deduper returns the Deduper described by d, or nil if deduplication is
+// disabled. d must have been validated.
+func (d Dedupe) deduper() *Deduper {
+	if d.Window == "" {
+		return nil
+	}
+	window, _ := time.ParseDuration(d.Window)
+	return &Deduper{Window: window}
+}
+
+// sampler returns the Sampler described by s, or nil if sampling is disabled.
+func (s Sampling) sampler() Sampler {
+	switch {
+	case s.Fraction == 0:
+		return nil
+	case s.By == SampleTransaction:
+		return ConsistentSampler(s.Fraction, s.Seed, SampleByTransaction)
+	case s.By == SampleDevice:
+		return ConsistentSampler(s.Fraction, s.Seed, SampleByDevice)
+	default:
+		return FixedRate(s.Fraction)
+	}
+}
+
+// FieldSpec is a field spec as accepted by ParseFields. Unmarshaling a
+// FieldSpec validates it.
+type FieldSpec string
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (fs *FieldSpec) UnmarshalText(text []byte) error {
+	if _, err := ParseFields(string(text)); err != nil {
+		return err
+	}
+	*fs = FieldSpec(text)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Unknown keys are rejected and the
+// config is validated.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	// config has the same fields without the methods, avoiding recursion.
+	type config Config
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg config
+	if err := dec.Decode(&cfg); err != nil {
+		return err
+	}
+	if err := Config(cfg).validate(); err != nil {
+		return err
+	}
+
+	*c = Config(cfg)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Unknown keys are rejected and
+// the config is validated.
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	// config has the same fields without the methods, avoiding recursion.
+	type config Config
+
+	// Only a yaml.Decoder can reject unknown keys, so the node is encoded
+	// again and decoded by one.
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var cfg config
+	if err := dec.Decode(&cfg); err != nil {
+		return err
+	}
+	if err := Config(cfg).validate(); err != nil {
+		return err
+	}
+
+	*c = Config(cfg)
+	return nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The text is the JSON
+// form of the config, which allows a whole config to be given as a single
+// string, such as an environment variable.
+func (c *Config) UnmarshalText(text []byte) error {
+	return c.UnmarshalJSON(text)
+}
+
+// validate checks the parts of the config that are not checked while
+// unmarshaling.
+func (c Config) validate() error {
+	switch c.Redaction {
+	case RedactionNone, RedactionMask:
+	case RedactionHMAC:
+		if c.RedactionKey == "" {
+			return fmt.Errorf("%w: redaction %q requires a redaction_key", ErrInvalidConfig, c.Redaction)
+		}
+	default:
+		return fmt.Errorf("%w: unknown redaction %q", ErrInvalidConfig, c.Redaction)
+	}
+
+	if f := c.Sampling.Fraction; f < 0 || f > 1 {
+		return fmt.Errorf("%w: sampling fraction %v is not between 0 and 1", ErrInvalidConfig, f)
+	}
+
+	switch c.Sampling.By {
+	case SampleRandom, SampleTransaction, SampleDevice:
+	default:
+		return fmt.Errorf("%w: unknown sampling %q", ErrInvalidConfig, c.Sampling.By)
+	}
+
+	if c.RateLimit.Rate < 0 || c.RateLimit.Burst < 0 {
+		return fmt.Errorf("%w: rate limit rate and burst must not be negative", ErrInvalidConfig)
+	}
+	switch c.RateLimit.By {
+	case LimitAll, LimitDevice, LimitType:
+	default:
+		return fmt.Errorf("%w: unknown rate limit %q", ErrInvalidConfig, c.RateLimit.By)
+	}
+
+	if c.Dedupe.Window != "" {
+		window, err := time.ParseDuration(c.Dedupe.Window)
+		if err != nil {
+			return fmt.Errorf("%w: dedupe window: %w", ErrInvalidConfig, err)
+		}
+		if window <= 0 {
+			return fmt.Errorf("%w: dedupe window %v is not positive", ErrInvalidConfig, window)
+		}
+	}
+	return nil
+}
+
+// NewObserver builds an Observer from cfg that logs to logger. The observer
+// is created and validated by New.
+func NewObserver(cfg Config, logger *slog.Logger) (*Observer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	fields, err := ParseFields(string(cfg.Fields))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := cfg.Message
+	if msg == "" {
+		msg = DefaultMessage
+	}
+
+	opts := []Option{
+		WithLogger(logger),
+		WithLevel(cfg.Level),
+		WithMessage(msg),
+		WithFields(fields...),
+		WithKeyNames(cfg.KeyNames),
+		WithKeyPrefix(cfg.KeyPrefix),
+		WithGroup(cfg.Group),
+		WithSampler(cfg.Sampling.sampler()),
+		WithLimiter(cfg.RateLimit.limiter()),
+		WithDeduper(cfg.Dedupe.deduper()),
+	}
+
+	switch cfg.Redaction {
+	case RedactionMask:
+		opts = append(opts, WithRedactor(MaskRedactor()))
+	case RedactionHMAC:
+		opts = append(opts, WithRedactor(HMACRedactor([]byte(cfg.RedactionKey))))
+	}
+
+	if cfg.Escalate {
+		opts = append(opts, WithEscalation(DefaultEscalation()))
+	}
+
+	return New(opts...)
+}
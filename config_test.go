@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_Unmarshal(t *testing.T) {
+	expected := Config{
+		Level:        slog.LevelDebug,
+		Message:      "wrp traffic",
+		Fields:       "msg_type:string,source,dest!",
+		Redaction:    RedactionHMAC,
+		RedactionKey: "secret",
+		Escalate:     true,
+		KeyNames:     map[string]string{"dest": "wrp.destination"},
+		KeyPrefix:    "wrp_",
+		Group:        "wrp",
+		Sampling:     Sampling{Fraction: 0.01, By: SampleTransaction, Seed: 42},
+		RateLimit:    RateLimit{Rate: 100, Burst: 200, By: LimitDevice},
+		Dedupe:       Dedupe{Window: "5s"},
+	}
+
+	const doc = `{
+		"level": "debug",
+		"message": "wrp traffic",
+		"fields": "msg_type:string,source,dest!",
+		"redaction": "hmac",
+		"redaction_key": "secret",
+		"escalate": true,
+		"key_names": {"dest": "wrp.destination"},
+		"key_prefix": "wrp_",
+		"group": "wrp",
+		"sampling": {"fraction": 0.01, "by": "transaction", "seed": 42},
+		"rate_limit": {"rate": 100, "burst": 200, "by": "device"},
+		"dedupe": {"window": "5s"}
+	}`
+
+	t.Run("json", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, json.Unmarshal([]byte(doc), &cfg))
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var cfg Config
+		require.NoError(t, cfg.UnmarshalText([]byte(doc)))
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		const doc = `
+level: debug
+message: wrp traffic
+fields: msg_type:string,source,dest!
+redaction: hmac
+redaction_key: secret
+escalate: true
+key_names:
+  dest: wrp.destination
+key_prefix: wrp_
+group: wrp
+sampling:
+  fraction: 0.01
+  by: transaction
+  seed: 42
+rate_limit:
+  rate: 100
+  burst: 200
+  by: device
+dedupe:
+  window: 5s
+`
+		var cfg Config
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &cfg))
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		var service struct {
+			WRPLog Config `json:"wrplog"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(`{"wrplog": `+doc+`}`), &service))
+		assert.Equal(t, expected, service.WRPLog)
+	})
+}
+
+func TestConfig_UnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected error
+	}{
+		{name: "unknown_field", doc: `{"fields": "source,bogus"}`, expected: ErrUnknownField},
+		{name: "invalid_field", doc: `{"fields": "source:bogus"}`, expected: ErrInvalidFieldSpec},
+		{name: "unknown_redaction", doc: `{"redaction": "rot13"}`, expected: ErrInvalidConfig},
+		{name: "missing_key", doc: `{"redaction": "hmac"}`, expected: ErrInvalidConfig},
+		{name: "bad_fraction", doc: `{"sampling": {"fraction": 1.5}}`, expected: ErrInvalidConfig},
+		{name: "unknown_sampling", doc: `{"sampling": {"by": "session"}}`, expected: ErrInvalidConfig},
+		{name: "negative_rate", doc: `{"rate_limit": {"rate": -1}}`, expected: ErrInvalidConfig},
+		{name: "unknown_rate_limit", doc: `{"rate_limit": {"by": "partner"}}`, expected: ErrInvalidConfig},
+		{name: "bad_window", doc: `{"dedupe": {"window": "soon"}}`, expected: ErrInvalidConfig},
+		{name: "negative_window", doc: `{"dedupe": {"window": "-1s"}}`, expected: ErrInvalidConfig},
+		{name: "unknown_key", doc: `{"levle": "debug"}`},
+		{name: "unknown_sampling_key", doc: `{"sampling": {"rate": 0.5}}`},
+		{name: "bad_level", doc: `{"level": "loud"}`},
+	}
+
+	// JSON documents are YAML documents as well.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, unmarshal := range map[string]func([]byte, any) error{
+				"json": json.Unmarshal,
+				"yaml": yaml.Unmarshal,
+			} {
+				var cfg Config
+				err := unmarshal([]byte(tt.doc), &cfg)
+				require.Error(t, err, name)
+				if tt.expected != nil {
+					assert.ErrorIs(t, err, tt.expected, name)
+				}
+			}
+		})
+	}
+
+	t.Run("yaml_block", func(t *testing.T) {
+		for _, doc := range []string{
+			"redaction: rot13\n",
+			"levle: debug\n",
+			"sampling:\n  fraction: 7\n",
+			"sampling:\n  rate: 0.5\n",
+		} {
+			var cfg Config
+			assert.Error(t, yaml.Unmarshal([]byte(doc), &cfg), doc)
+		}
+	})
+}
+
+func TestNewObserver(t *testing.T) {
+	handler := newRecordHandler(slog.LevelDebug)
+	ob, err := NewObserver(Config{
+		Level:     slog.LevelDebug,
+		Fields:    "source,device_id",
+		Redaction: RedactionMask,
+		Escalate:  true,
+	}, slog.New(handler))
+	require.NoError(t, err)
+
+	status := int64(503)
+	ob.ObserveWRP(context.Background(), wrp.Message{Source: "mac:112233445566", Status: &status})
+
+	require.Len(t, handler.records, 1)
+	record := handler.records[0]
+	assert.Equal(t, DefaultMessage, record.Message)
+	assert.Equal(t, slog.LevelError, record.Level)
+
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 2)
+	assert.Equal(t, "mac:************", attrs[0].Value.String())
+	assert.Equal(t, "mac:************", attrs[1].Value.String())
+}
+
+func TestNewObserver_Sampling(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob, err := NewObserver(Config{
+		Fields:   "source",
+		Sampling: Sampling{Fraction: 1},
+	}, slog.New(handler))
+	require.NoError(t, err)
+
+	ob.ObserveWRP(context.Background(), wrp.Message{Source: "dns:example.com"})
+
+	require.Len(t, handler.records, 1)
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 2)
+	assert.True(t, slog.Float64(fSampleRate, 1).Equal(attrs[1]))
+}
+
+func TestNewObserver_Dedupe(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob, err := NewObserver(Config{
+		Fields: "source",
+		Dedupe: Dedupe{Window: "1h"},
+	}, slog.New(handler))
+	require.NoError(t, err)
+	require.NotNil(t, ob.Deduper)
+	assert.Equal(t, time.Hour, ob.Deduper.Window)
+
+	msg := wrp.Message{Source: "mac:112233445566"}
+	ob.ObserveWRP(context.Background(), msg)
+	ob.ObserveWRP(context.Background(), msg)
+	assert.Len(t, handler.records, 1)
+}
+
+func TestNewObserver_Errors(t *testing.T) {
+	_, err := NewObserver(Config{Fields: "bogus"}, slog.Default())
+	assert.ErrorIs(t, err, ErrUnknownField)
+
+	_, err = NewObserver(Config{Redaction: RedactionHMAC}, slog.Default())
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewObserver(Config{KeyNames: map[string]string{"bogus": "x"}}, slog.Default())
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewObserver(Config{Sampling: Sampling{Fraction: -0.5}}, slog.Default())
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+const fRepeatCount = "repeat_count"
+
+// Deduper defaults.
+const (
+	// DefaultDedupeWindow is the window of a Deduper when Window is not set.
+	DefaultDedupeWindow = 5 * time.Second
+
+	// DefaultDedupeEntries is the number of messages a Deduper tracks when
+	// MaxEntries is not set.
+	DefaultDedupeEntries = 10000
+
+	// DefaultRepeatedMessage is the log message of repeated message
+	// summaries when SummaryMessage is not set.
+	DefaultRepeatedMessage = "wrp message repeated"
+)
+
+// Deduper suppresses repeats of a message, such as the retries of a device,
+// within a window. Messages are the same when they have the same type,
+// source, destination, transaction UUID and payload.
+//
+// The first message is logged and its repeats within Window are dropped and
+// counted. The count is logged as repeat_count on the next copy of the
+// message logged after the window, or, if there is none, on a summary record
+// under SummaryMessage. A summary logs the configured fields of a copy of the
+// message that keeps only its type, source, destination, transaction UUID,
+// status and request delivery response; fields derived from the payload,
+// headers, metadata or anything else are omitted. See Observer.Run.
+//
+// A Deduper must be used as a pointer and must not be copied after first use.
+// Its fields are read on first use. It may be shared by several observers.
+type Deduper struct {
+	// Window is how long repeats of a message are suppressed for. Defaults
+	// to DefaultDedupeWindow.
+	Window time.Duration
+
+	// MaxEntries bounds the number of messages tracked. Once it is reached,
+	// new messages are logged without being tracked until old ones expire.
+	// Defaults to DefaultDedupeEntries.
+	MaxEntries int
+
+	// SummaryMessage is the log message of summaries. Defaults to
+	// DefaultRepeatedMessage.
+	SummaryMessage string
+
+	once       sync.Once
+	window     time.Duration
+	maxEntries int
+	message    string
+	mu         sync.Mutex
+	entries    map[dedupeKey]*dedupeEntry
+	lastSweep  time.Time
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// dedupeKey identifies repeats of a message. It holds the fields themselves
+// rather than a hash of them so that messages cannot be made to collide.
+type dedupeKey struct {
+	msgType         wrp.MessageType
+	source          string
+	destination     string
+	transactionUUID string
+	payload         [sha256.Size]byte
+}
+
+// dedupeEntry tracks the repeats of a message within a window.
+type dedupeEntry struct {
+	start   time.Time
+	repeats int64
+
+	// msg is the trimmed first message of the window, logged by summaries.
+	msg wrp.Message
+}
+
+// repeated is the summary of the repeats of a message that were not logged
+// with a later copy of the message.
+type repeated struct {
+	msg   wrp.Message
+	count int64
+	start time.Time
+}
+
+func (d *Deduper) init() {
+	d.once.Do(func() {
+		d.window = cmp.Or(max(d.Window, 0), DefaultDedupeWindow)
+		d.maxEntries = cmp.Or(max(d.MaxEntries, 0), DefaultDedupeEntries)
+		d.message = cmp.Or(d.SummaryMessage, DefaultRepeatedMessage)
+		if d.now == nil {
+			d.now = time.Now
+		}
+		d.entries = make(map[dedupeKey]*dedupeEntry)
+		d.lastSweep = d.now()
+	})
+}
+
+// check reports whether msg may be logged and, if it may, how many repeats of
+// it were dropped before it. It also returns the summaries that are due.
+func (d *Deduper) check(msg wrp.Message) (ok bool, repeats int64, due []repeated) {
+	d.init()
+	key := fingerprint(msg)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	switch e := d.entries[key]; {
+	case e == nil:
+		if len(d.entries) < d.maxEntries {
+			d.entries[key] = &dedupeEntry{start: now, msg: trimmed(msg)}
+		}
+		ok = true
+	case now.Sub(e.start) < d.window:
+		e.repeats++
+	default:
+		ok, repeats = true, e.repeats
+		e.start, e.repeats, e.msg = now, 0, trimmed(msg)
+	}
+
+	if now.Sub(d.lastSweep) >= d.window {
+		due = d.sweep(now, false)
+	}
+	return ok, repeats, due
+}
+
+// restore adds repeats back to the count of msg, for a copy of msg that
+// check allowed with repeats but that was not logged after all.
+func (d *Deduper) restore(msg wrp.Message, repeats int64) {
+	key := fingerprint(msg)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if e := d.entries[key]; e != nil {
+		e.repeats += repeats
+	}
+}
+
+// due returns the summaries of the messages whose window has ended if Window
+// has passed since they were last checked.
+func (d *Deduper) due() []repeated {
+	d.init()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastSweep) < d.window {
+		return nil
+	}
+	return d.sweep(now, false)
+}
+
+// flush returns the summaries of all messages with repeats that have not been
+// logged, whether or not their window has ended.
+func (d *Deduper) flush() []repeated {
+	d.init()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sweep(d.now(), true)
+}
+
+// sweep drops the messages whose window has ended, and returns the summaries
+// of those that were repeated. If all is set, the repeats of messages whose
+// window has not ended are summarized and reset as well. It must be called
+// with mu held.
+func (d *Deduper) sweep(now time.Time, all bool) []repeated {
+	d.lastSweep = now
+
+	var due []repeated
+	for key, e := range d.entries {
+		expired := now.Sub(e.start) >= d.window
+		if e.repeats > 0 && (expired || all) {
+			due = append(due, repeated{msg: e.msg, count: e.repeats, start: e.start})
+			e.repeats = 0
+		}
+		if expired {
+			delete(d.entries, key)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b repeated) int {
+		return a.start.Compare(b.start)
+	})
+	return due
+}
+
+// fingerprint returns the key that identifies repeats of msg. The payload is
+// identified by its SHA-256 digest, as logged by PayloadDigest(DigestSHA256).
+func fingerprint(msg wrp.Message) dedupeKey {
+	return dedupeKey{
+		msgType:         msg.Type,
+		source:          msg.Source,
+		destination:     msg.Destination,
+		transactionUUID: msg.TransactionUUID,
+		payload:         sha256.Sum256(msg.Payload),
+	}
+}
+
+// trimmed returns the parts of msg that summaries log: the fields that
+// identify it and those that decide its level. Nothing is shared with msg, so
+// tracked messages neither pin large payloads nor change when the caller
+// reuses its buffers.
+func trimmed(msg wrp.Message) wrp.Message {
+	t := wrp.Message{
+		Type:            msg.Type,
+		Source:          msg.Source,
+		Destination:     msg.Destination,
+		TransactionUUID: msg.TransactionUUID,
+	}
+	if msg.Status != nil {
+		status := *msg.Status
+		t.Status = &status
+	}
+	if msg.RequestDeliveryResponse != nil {
+		rdr := *msg.RequestDeliveryResponse
+		t.RequestDeliveryResponse = &rdr
+	}
+	return t
+}
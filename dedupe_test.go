@@ -0,0 +1,304 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// dedupedObserver returns an observer that logs the source and repeat count
+// of messages through deduper, and the handler it logs to.
+func dedupedObserver(deduper *Deduper, clock *fakeClock) (*Observer, *recordHandler) {
+	deduper.now = clock.now
+	handler := newRecordHandler(slog.LevelInfo)
+	return &Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source()},
+		Deduper: deduper,
+	}, handler
+}
+
+// repeatCount returns the repeat count logged by record i, or -1 if there is
+// none.
+func repeatCount(handler *recordHandler, i int) int64 {
+	for _, attr := range handler.getAttrs(i) {
+		if attr.Key == fRepeatCount {
+			return attr.Value.Int64()
+		}
+	}
+	return -1
+}
+
+func TestDeduper(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := dedupedObserver(&Deduper{Window: time.Minute}, clock)
+	ctx := context.Background()
+	msg := wrp.Message{
+		Type:            wrp.SimpleEventMessageType,
+		Source:          "mac:112233445566",
+		Destination:     "event:device-status/mac:112233445566/online",
+		TransactionUUID: "txn",
+		Payload:         []byte(`{"status":"online"}`),
+	}
+
+	ob.ObserveWRP(ctx, msg)
+	for range 3 {
+		clock.advance(time.Second)
+		ob.ObserveWRP(ctx, msg)
+	}
+	require.Len(t, handler.records, 1, "repeats within the window are dropped")
+	assert.Equal(t, int64(-1), repeatCount(handler, 0))
+
+	clock.advance(time.Minute)
+	ob.ObserveWRP(ctx, msg)
+	require.Len(t, handler.records, 2)
+	assert.Equal(t, "wrp message", handler.records[1].Message)
+	assert.Equal(t, int64(3), repeatCount(handler, 1), "the next copy carries the repeat count")
+
+	clock.advance(time.Second)
+	ob.ObserveWRP(ctx, msg)
+	clock.advance(2 * time.Minute)
+	ob.ObserveWRP(ctx, msg)
+	require.Len(t, handler.records, 3)
+	assert.Equal(t, int64(1), repeatCount(handler, 2))
+}
+
+func TestDeduper_Fingerprint(t *testing.T) {
+	msg := wrp.Message{
+		Type:            wrp.SimpleEventMessageType,
+		Source:          "mac:112233445566",
+		Destination:     "event:device-status",
+		TransactionUUID: "txn",
+		Payload:         []byte("a"),
+	}
+
+	tests := []struct {
+		name   string
+		change func(*wrp.Message)
+	}{
+		{name: "type", change: func(m *wrp.Message) { m.Type = wrp.SimpleRequestResponseMessageType }},
+		{name: "source", change: func(m *wrp.Message) { m.Source = "mac:665544332211" }},
+		{name: "destination", change: func(m *wrp.Message) { m.Destination = "event:other" }},
+		{name: "transaction_uuid", change: func(m *wrp.Message) { m.TransactionUUID = "other" }},
+		{name: "payload", change: func(m *wrp.Message) { m.Payload = []byte("b") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, handler := dedupedObserver(&Deduper{}, newFakeClock())
+			other := msg
+			tt.change(&other)
+
+			ob.ObserveWRP(context.Background(), msg)
+			ob.ObserveWRP(context.Background(), other)
+			assert.Len(t, handler.records, 2)
+		})
+	}
+
+	t.Run("other_fields", func(t *testing.T) {
+		ob, handler := dedupedObserver(&Deduper{}, newFakeClock())
+		other := msg
+		other.Headers = []string{"X-Retry: 1"}
+		other.Payload = []byte("a")
+
+		ob.ObserveWRP(context.Background(), msg)
+		ob.ObserveWRP(context.Background(), other)
+		assert.Len(t, handler.records, 1)
+	})
+}
+
+func TestDeduper_Summary(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := dedupedObserver(&Deduper{Window: time.Minute}, clock)
+	ctx := context.Background()
+	retried := wrp.Message{Source: "mac:112233445566"}
+
+	ob.ObserveWRP(ctx, retried)
+	ob.ObserveWRP(ctx, retried)
+	ob.ObserveWRP(ctx, retried)
+
+	// Other traffic after the window reports the repeats that no later copy
+	// of the message carried.
+	clock.advance(time.Minute)
+	ob.ObserveWRP(ctx, wrp.Message{Source: "dns:example.com"})
+
+	require.Len(t, handler.records, 3)
+	assert.Equal(t, DefaultRepeatedMessage, handler.records[1].Message)
+	assert.Equal(t, "mac:112233445566", handler.getAttrs(1)[0].Value.String())
+	assert.Equal(t, int64(2), repeatCount(handler, 1))
+	assert.Equal(t, "wrp message", handler.records[2].Message)
+
+	ob.ObserveWRP(ctx, retried)
+	assert.Len(t, handler.records, 4, "the message is logged again after its summary")
+	assert.Equal(t, int64(-1), repeatCount(handler, 3))
+}
+
+func TestDeduper_SummaryTrimmed(t *testing.T) {
+	ob, handler := dedupedObserver(&Deduper{}, newFakeClock())
+	ob.Fields = []FieldOpt{Source(), Status(), PayloadSize(), Metadata()}
+	ctx := context.Background()
+
+	status := int64(200)
+	msg := wrp.Message{
+		Source:   "mac:112233445566",
+		Status:   &status,
+		Payload:  []byte("payload"),
+		Metadata: map[string]string{"hw-model": "XB7"},
+	}
+
+	ob.ObserveWRP(ctx, msg)
+	ob.ObserveWRP(ctx, msg)
+
+	// The caller reuses its buffers.
+	status = 500
+	msg.Payload[0] = 'P'
+
+	ob.Flush(ctx)
+	require.Len(t, handler.records, 2)
+	assert.Equal(t, DefaultRepeatedMessage, handler.records[1].Message)
+	attrs := handler.getAttrs(1)
+	require.Len(t, attrs, 3, "payload and metadata fields are not kept")
+	assert.True(t, slog.String(fSource, "mac:112233445566").Equal(attrs[0]))
+	assert.True(t, slog.Int64(fStatus, 200).Equal(attrs[1]))
+	assert.True(t, slog.Int64(fRepeatCount, 1).Equal(attrs[2]))
+}
+
+func TestDeduper_Flush(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := dedupedObserver(&Deduper{SummaryMessage: "repeated"}, clock)
+	ob.Level = slog.LevelDebug
+	ob.Escalation = DefaultEscalation()
+	ctx := context.Background()
+
+	status := int64(500)
+	failed := wrp.Message{Source: "mac:112233445566", Status: &status}
+	quiet := wrp.Message{Source: "mac:665544332211"}
+
+	ob.ObserveWRP(ctx, failed)
+	ob.ObserveWRP(ctx, failed)
+	ob.ObserveWRP(ctx, quiet)
+	ob.ObserveWRP(ctx, quiet)
+	require.Len(t, handler.records, 1, "debug messages are not logged")
+
+	ob.Flush(ctx)
+	require.Len(t, handler.records, 2, "summaries are logged at the message's level")
+	assert.Equal(t, "repeated", handler.records[1].Message)
+	assert.Equal(t, slog.LevelError, handler.records[1].Level)
+	assert.Equal(t, int64(1), repeatCount(handler, 1))
+
+	ob.ObserveWRP(ctx, failed)
+	ob.Flush(ctx)
+	assert.Len(t, handler.records, 3, "flushed repeats are reset, but the window goes on")
+}
+
+func TestDeduper_Run(t *testing.T) {
+	records := make(chanHandler, 10)
+	ob := Observer{
+		Logger:  slog.New(records),
+		Message: "wrp message",
+		Limiter: &Limiter{Rate: 1000, Interval: time.Hour},
+		Deduper: &Deduper{Window: 10 * time.Millisecond},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	msg := wrp.Message{Source: "mac:112233445566"}
+
+	for range 3 {
+		ob.ObserveWRP(ctx, msg)
+	}
+	require.Equal(t, "wrp message", (<-records).Message)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ob.Run(ctx)
+	}()
+
+	// The summary is logged once the window ends, without waiting for
+	// another message or the limiter's interval.
+	select {
+	case record := <-records:
+		assert.Equal(t, DefaultRepeatedMessage, record.Message)
+		var count int64
+		record.Attrs(func(a slog.Attr) bool {
+			if a.Key == fRepeatCount {
+				count = a.Value.Int64()
+			}
+			return true
+		})
+		assert.Equal(t, int64(2), count)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no summary was logged")
+	}
+
+	cancel()
+	<-done
+	assert.Empty(t, records)
+}
+
+func TestDeduper_MaxEntries(t *testing.T) {
+	ob, handler := dedupedObserver(&Deduper{MaxEntries: 1}, newFakeClock())
+	ctx := context.Background()
+
+	first := wrp.Message{Source: "mac:112233445566"}
+	second := wrp.Message{Source: "mac:665544332211"}
+
+	ob.ObserveWRP(ctx, first)
+	ob.ObserveWRP(ctx, first)
+	ob.ObserveWRP(ctx, second)
+	ob.ObserveWRP(ctx, second)
+	assert.Len(t, handler.records, 3, "messages over the limit are not tracked")
+}
+
+func TestDeduper_RateLimited(t *testing.T) {
+	t.Run("repeats_do_not_use_tokens", func(t *testing.T) {
+		clock := newFakeClock()
+		ob, handler := dedupedObserver(&Deduper{}, clock)
+		ob.Limiter = &Limiter{Rate: 1, now: clock.now}
+		ctx := context.Background()
+
+		msg := wrp.Message{Source: "mac:112233445566"}
+		for range 5 {
+			ob.ObserveWRP(ctx, msg)
+		}
+		ob.ObserveWRP(ctx, wrp.Message{Source: "mac:665544332211"})
+		ob.Flush(ctx)
+
+		require.Len(t, handler.records, 3)
+		assert.Equal(t, DefaultRepeatedMessage, handler.records[1].Message, "repeats do not use up the rate limit")
+		assert.Equal(t, int64(4), repeatCount(handler, 1))
+		assert.Equal(t, DefaultSuppressedMessage, handler.records[2].Message)
+	})
+
+	t.Run("dropped_copy_keeps_repeats", func(t *testing.T) {
+		clock := newFakeClock()
+		ob, handler := dedupedObserver(&Deduper{Window: time.Minute}, clock)
+		ob.Limiter = &Limiter{Rate: 1.0 / 3600, Burst: 1, now: clock.now}
+		ctx := context.Background()
+
+		msg := wrp.Message{Source: "mac:112233445566"}
+		for range 4 {
+			ob.ObserveWRP(ctx, msg)
+		}
+		clock.advance(2 * time.Minute)
+		ob.ObserveWRP(ctx, msg)
+		ob.Flush(ctx)
+
+		var messages []string
+		for _, record := range handler.records {
+			messages = append(messages, record.Message)
+		}
+		assert.Equal(t, []string{"wrp message", DefaultSuppressedMessage, DefaultRepeatedMessage}, messages)
+		assert.Equal(t, [][2]string{{"1", ""}}, summaries(handler))
+		assert.Equal(t, int64(3), repeatCount(handler, 2), "the repeats of the dropped copy are not lost")
+	})
+}
@@ -5,6 +5,7 @@ package wrpslog_test
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"os"
 
@@ -89,3 +90,41 @@ func Example_alwaysFields() {
 	// Output:
 	// msg="wrp message" msg_type=4 source=dns:talaria.example.com/service dest="" status=0
 }
+
+func ExampleNewObserver() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Remove time and level for consistent example output
+			if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	// The config would normally come from a JSON or YAML file.
+	var cfg wrpslog.Config
+	err := json.Unmarshal([]byte(`{
+		"level": "info",
+		"fields": "msg_type,source,dest,transaction_uuid",
+		"redaction": "mask"
+	}`), &cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	ob, err := wrpslog.NewObserver(cfg, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "dns:talaria.example.com/service",
+		Destination:     "mac:112233445566/config",
+		TransactionUUID: "546514d4-9cb6-41c9-88ca-ccd4c130c525",
+	})
+
+	// Output:
+	// msg="wrp message" msg_type=3 source=dns:talaria.example.com/service dest=mac:************/config transaction_uuid=546514d4-9cb6-41c9-88ca-ccd4c130c525
+}
@@ -6,6 +6,10 @@ package wrpslog
 import (
 	"encoding/base64"
 	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/xmidt-org/wrp-go/v5"
 )
@@ -54,17 +58,54 @@ const (
 	idxPartnerIDs
 	idxSessionID
 	idxQualityOfService
+	idxDeviceID
+	idxEventType
+	idxEventSubpath
+	idxPayloadDigest
 	fieldCount // Total number of field slots
 )
 
+// slotNames holds the name each field slot is logged under.
+var slotNames = [fieldCount]string{
+	idxMsgType:                 fMsgType,
+	idxSource:                  fSource,
+	idxDestination:             fDestination,
+	idxTransactionUUID:         fTransactionUUID,
+	idxContentType:             fContentType,
+	idxAccept:                  fAccept,
+	idxStatus:                  fStatus,
+	idxRequestDeliveryResponse: fRequestDeliveryResponse,
+	idxHeaders:                 fHeaders,
+	idxMetadata:                fMetadata,
+	idxPath:                    fPath,
+	idxPayload:                 fPayload,
+	idxPayloadSize:             fPayloadSize,
+	idxServiceName:             fServiceName,
+	idxURL:                     fURL,
+	idxPartnerIDs:              fPartnerIDs,
+	idxSessionID:               fSessionID,
+	idxQualityOfService:        fQualityOfService,
+	idxDeviceID:                fDeviceID,
+	idxEventType:               fEventType,
+	idxEventSubpath:            fEventSubpath,
+	idxPayloadDigest:           fPayloadDigest,
+}
+
+// String returns the name the slot is logged under.
+func (i fieldIndex) String() string {
+	return slotNames[i]
+}
+
 // FieldOpt configures a field to be logged by the Observer.
-// Each FieldOpt sets a specific slot in the Observer's internal field array.
+// Each FieldOpt sets a specific slot in the Observer's compiled field plan.
 // Calling multiple options for the same field (e.g., MessageType and
-// MessageTypeAsString) will overwrite - the last one wins.
-type FieldOpt func(*Observer)
+// MessageTypeAsString) will overwrite - the last one wins. Custom fields
+// created with Field or FieldAlways are appended instead.
+type FieldOpt func(*plan)
 
 // fieldFunc extracts a field from a WRP message and returns it as an slog.Attr.
 // Returns an slog.Attr with an empty Key to indicate the field should be skipped.
+// A group with an empty Key is logged with its attrs inlined.
 type fieldFunc func(wrp.Message) slog.Attr
 
 // MessageType logs the message type as a number. This is an alias for MessageTypeAsNum.
@@ -75,147 +116,165 @@ func MessageType() FieldOpt {
 // MessageTypeAsString logs the message type as a human-readable string.
 // Uses the same slot as MessageType/MessageTypeAsNum.
 func MessageTypeAsString() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxMsgType] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fMsgType, msg.Type.String())
+	return func(p *plan) {
+		key := p.key(fMsgType)
+		p.fields[idxMsgType] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.Type.String())
 		}
 	}
 }
 
 // MessageTypeAsNum logs the message type as a number.
 func MessageTypeAsNum() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxMsgType] = func(msg wrp.Message) slog.Attr {
-			return slog.Int(fMsgType, int(msg.Type))
+	return func(p *plan) {
+		key := p.key(fMsgType)
+		p.fields[idxMsgType] = func(msg wrp.Message) slog.Attr {
+			return slog.Int(key, int(msg.Type))
 		}
 	}
 }
 
 // Source logs the source of the message. Empty values are omitted.
 func Source() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxSource] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fSource)
+		redact := p.redactor
+		p.fields[idxSource] = func(msg wrp.Message) slog.Attr {
 			if msg.Source == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fSource, msg.Source)
+			return slog.String(key, redactDeviceIDs(msg.Source, redact))
 		}
 	}
 }
 
 // SourceAlways logs the source of the message, even when empty.
 func SourceAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxSource] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fSource, msg.Source)
+	return func(p *plan) {
+		key := p.key(fSource)
+		redact := p.redactor
+		p.fields[idxSource] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, redactDeviceIDs(msg.Source, redact))
 		}
 	}
 }
 
 // Destination logs the destination of the message. Empty values are omitted.
 func Destination() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxDestination] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fDestination)
+		redact := p.redactor
+		p.fields[idxDestination] = func(msg wrp.Message) slog.Attr {
 			if msg.Destination == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fDestination, msg.Destination)
+			return slog.String(key, redactDeviceIDs(msg.Destination, redact))
 		}
 	}
 }
 
 // DestinationAlways logs the destination of the message, even when empty.
 func DestinationAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxDestination] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fDestination, msg.Destination)
+	return func(p *plan) {
+		key := p.key(fDestination)
+		redact := p.redactor
+		p.fields[idxDestination] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, redactDeviceIDs(msg.Destination, redact))
 		}
 	}
 }
 
 // TransactionUUID logs the transaction UUID of the message. Empty values are omitted.
 func TransactionUUID() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxTransactionUUID] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fTransactionUUID)
+		p.fields[idxTransactionUUID] = func(msg wrp.Message) slog.Attr {
 			if msg.TransactionUUID == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fTransactionUUID, msg.TransactionUUID)
+			return slog.String(key, msg.TransactionUUID)
 		}
 	}
 }
 
 // TransactionUUIDAlways logs the transaction UUID of the message, even when empty.
 func TransactionUUIDAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxTransactionUUID] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fTransactionUUID, msg.TransactionUUID)
+	return func(p *plan) {
+		key := p.key(fTransactionUUID)
+		p.fields[idxTransactionUUID] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.TransactionUUID)
 		}
 	}
 }
 
 // ContentType logs the content type of the message. Empty values are omitted.
 func ContentType() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxContentType] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fContentType)
+		p.fields[idxContentType] = func(msg wrp.Message) slog.Attr {
 			if msg.ContentType == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fContentType, msg.ContentType)
+			return slog.String(key, msg.ContentType)
 		}
 	}
 }
 
 // ContentTypeAlways logs the content type of the message, even when empty.
 func ContentTypeAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxContentType] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fContentType, msg.ContentType)
+	return func(p *plan) {
+		key := p.key(fContentType)
+		p.fields[idxContentType] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.ContentType)
 		}
 	}
 }
 
 // Accept logs the accept header of the message. Empty values are omitted.
 func Accept() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxAccept] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fAccept)
+		p.fields[idxAccept] = func(msg wrp.Message) slog.Attr {
 			if msg.Accept == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fAccept, msg.Accept)
+			return slog.String(key, msg.Accept)
 		}
 	}
 }
 
 // AcceptAlways logs the accept header of the message, even when empty.
 func AcceptAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxAccept] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fAccept, msg.Accept)
+	return func(p *plan) {
+		key := p.key(fAccept)
+		p.fields[idxAccept] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.Accept)
 		}
 	}
 }
 
 // Status logs the status of the message. Nil values are omitted.
 func Status() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxStatus] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fStatus)
+		p.fields[idxStatus] = func(msg wrp.Message) slog.Attr {
 			if msg.Status == nil {
 				return slog.Attr{}
 			}
-			return slog.Int64(fStatus, *msg.Status)
+			return slog.Int64(key, *msg.Status)
 		}
 	}
 }
 
 // StatusAlways logs the status of the message, even when nil (logs 0).
 func StatusAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxStatus] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fStatus)
+		p.fields[idxStatus] = func(msg wrp.Message) slog.Attr {
 			if msg.Status == nil {
-				return slog.Int64(fStatus, 0)
+				return slog.Int64(key, 0)
 			}
-			return slog.Int64(fStatus, *msg.Status)
+			return slog.Int64(key, *msg.Status)
 		}
 	}
 }
@@ -223,12 +282,13 @@ func StatusAlways() FieldOpt {
 // RequestDeliveryResponse logs the request delivery response of the message.
 // Nil values are omitted.
 func RequestDeliveryResponse() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxRequestDeliveryResponse] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fRequestDeliveryResponse)
+		p.fields[idxRequestDeliveryResponse] = func(msg wrp.Message) slog.Attr {
 			if msg.RequestDeliveryResponse == nil {
 				return slog.Attr{}
 			}
-			return slog.Int64(fRequestDeliveryResponse, *msg.RequestDeliveryResponse)
+			return slog.Int64(key, *msg.RequestDeliveryResponse)
 		}
 	}
 }
@@ -236,75 +296,233 @@ func RequestDeliveryResponse() FieldOpt {
 // RequestDeliveryResponseAlways logs the request delivery response of the
 // message, even when nil (logs 0).
 func RequestDeliveryResponseAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxRequestDeliveryResponse] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fRequestDeliveryResponse)
+		p.fields[idxRequestDeliveryResponse] = func(msg wrp.Message) slog.Attr {
 			if msg.RequestDeliveryResponse == nil {
-				return slog.Int64(fRequestDeliveryResponse, 0)
+				return slog.Int64(key, 0)
 			}
-			return slog.Int64(fRequestDeliveryResponse, *msg.RequestDeliveryResponse)
+			return slog.Int64(key, *msg.RequestDeliveryResponse)
 		}
 	}
 }
 
-// Headers logs the headers of the message. Empty values are omitted.
+// Headers logs the headers of the message as a group. Headers in
+// "name: value" form are logged as name=value; any other header is logged
+// under its index. If that would log two headers under the same key, such as
+// a repeated header name, every header is logged whole under its index
+// instead. Empty values are omitted.
 func Headers() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxHeaders] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fHeaders)
+		redact := p.redactor
+		p.fields[idxHeaders] = func(msg wrp.Message) slog.Attr {
 			if len(msg.Headers) == 0 {
 				return slog.Attr{}
 			}
-			return slog.Any(fHeaders, msg.Headers)
+			return slog.Attr{Key: key, Value: headersValue(msg.Headers, redact)}
 		}
 	}
 }
 
-// HeadersAlways logs the headers of the message, even when empty.
+// HeadersAlways logs the headers of the message as a group, even when empty.
+// Handlers drop empty groups, so empty headers are logged as an empty list.
 func HeadersAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxHeaders] = func(msg wrp.Message) slog.Attr {
-			return slog.Any(fHeaders, msg.Headers)
+	return func(p *plan) {
+		key := p.key(fHeaders)
+		redact := p.redactor
+		p.fields[idxHeaders] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Headers) == 0 {
+				return slog.Any(key, msg.Headers)
+			}
+			return slog.Attr{Key: key, Value: headersValue(msg.Headers, redact)}
 		}
 	}
 }
 
-// Metadata logs the metadata of the message. Empty values are omitted.
+// Metadata logs the metadata of the message as a group with sorted keys.
+// Empty values are omitted.
 func Metadata() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fMetadata)
+		redact := p.redactor
+		p.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
 			if len(msg.Metadata) == 0 {
 				return slog.Attr{}
 			}
-			return slog.Any(fMetadata, msg.Metadata)
+			return slog.Attr{Key: key, Value: metadataValue(msg.Metadata, redact)}
 		}
 	}
 }
 
-// MetadataAlways logs the metadata of the message, even when empty.
+// MetadataAlways logs the metadata of the message as a group with sorted
+// keys, even when empty. Handlers drop empty groups, so empty metadata is
+// logged as an empty map.
 func MetadataAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
-			return slog.Any(fMetadata, msg.Metadata)
+	return func(p *plan) {
+		key := p.key(fMetadata)
+		redact := p.redactor
+		p.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Metadata) == 0 {
+				return slog.Any(key, msg.Metadata)
+			}
+			return slog.Attr{Key: key, Value: metadataValue(msg.Metadata, redact)}
 		}
 	}
 }
 
+// MetadataKeys logs only the listed metadata keys as a group, e.g.
+// metadata.hw-model. Keys are logged in sorted order and missing keys are
+// skipped. Uses the same slot as Metadata/MetadataAlways. The group is
+// omitted when none of the keys are present.
+func MetadataKeys(keys ...string) FieldOpt {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	return func(p *plan) {
+		key := p.key(fMetadata)
+		redact := p.redactor
+		p.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Metadata) == 0 {
+				return slog.Attr{}
+			}
+
+			attrs := make([]slog.Attr, 0, len(keys))
+			for _, k := range keys {
+				if v, found := msg.Metadata[k]; found {
+					attrs = append(attrs, slog.String(k, redactDeviceIDs(v, redact)))
+				}
+			}
+			return metadataGroup(key, attrs)
+		}
+	}
+}
+
+// MetadataExcept logs every metadata key except the listed ones as a group.
+// Keys are logged in sorted order. Uses the same slot as
+// Metadata/MetadataAlways. The group is omitted when no keys remain.
+func MetadataExcept(keys ...string) FieldOpt {
+	deny := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		deny[k] = struct{}{}
+	}
+
+	return func(p *plan) {
+		key := p.key(fMetadata)
+		redact := p.redactor
+		p.fields[idxMetadata] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Metadata) == 0 {
+				return slog.Attr{}
+			}
+
+			attrs := make([]slog.Attr, 0, len(msg.Metadata))
+			for k, v := range msg.Metadata {
+				if _, denied := deny[k]; !denied {
+					attrs = append(attrs, slog.String(k, redactDeviceIDs(v, redact)))
+				}
+			}
+			sortAttrs(attrs)
+			return metadataGroup(key, attrs)
+		}
+	}
+}
+
+// headersValue returns headers as a group value with device identifiers
+// redacted.
+func headersValue(headers []string, redact Redactor) slog.Value {
+	attrs := make([]slog.Attr, 0, len(headers))
+	for i, h := range headers {
+		// Headers are redacted whole, as a header such as mac:112233445566
+		// would otherwise be split into the name mac and an identifier.
+		h = redactDeviceIDs(h, redact)
+		name, value, found := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			attrs = append(attrs, slog.String(strconv.Itoa(i), h))
+			continue
+		}
+		attrs = append(attrs, slog.String(name, strings.TrimSpace(value)))
+	}
+
+	if !uniqueKeys(attrs) {
+		for i, h := range headers {
+			attrs[i] = slog.String(strconv.Itoa(i), redactDeviceIDs(h, redact))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// uniqueKeys reports whether no two of attrs have the same key.
+func uniqueKeys(attrs []slog.Attr) bool {
+	// Messages have few headers, so comparing every pair is cheaper than a
+	// map unless there are many.
+	if len(attrs) <= 16 {
+		for i := range attrs {
+			for j := range i {
+				if attrs[i].Key == attrs[j].Key {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	seen := make(map[string]struct{}, len(attrs))
+	for _, attr := range attrs {
+		if _, found := seen[attr.Key]; found {
+			return false
+		}
+		seen[attr.Key] = struct{}{}
+	}
+	return true
+}
+
+// metadataValue returns md as a group value with sorted keys.
+func metadataValue(md map[string]string, redact Redactor) slog.Value {
+	attrs := make([]slog.Attr, 0, len(md))
+	for k, v := range md {
+		attrs = append(attrs, slog.String(k, redactDeviceIDs(v, redact)))
+	}
+	sortAttrs(attrs)
+	return slog.GroupValue(attrs...)
+}
+
+// sortAttrs sorts attrs by key.
+func sortAttrs(attrs []slog.Attr) {
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+}
+
+// metadataGroup returns attrs as the metadata group under key, or an empty
+// slog.Attr if there are no attrs.
+func metadataGroup(key string, attrs []slog.Attr) slog.Attr {
+	if len(attrs) == 0 {
+		return slog.Attr{}
+	}
+	return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+}
+
 // Path logs the path of the message. Empty values are omitted.
 func Path() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPath] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fPath)
+		p.fields[idxPath] = func(msg wrp.Message) slog.Attr {
 			if msg.Path == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fPath, msg.Path)
+			return slog.String(key, msg.Path)
 		}
 	}
 }
 
 // PathAlways logs the path of the message, even when empty.
 func PathAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPath] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fPath, msg.Path)
+	return func(p *plan) {
+		key := p.key(fPath)
+		p.fields[idxPath] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.Path)
 		}
 	}
 }
@@ -312,12 +530,13 @@ func PathAlways() FieldOpt {
 // PayloadAsBase64 logs the payload of the message as base64 encoded string.
 // Empty values are omitted.
 func PayloadAsBase64() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fPayload)
+		p.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
 			if len(msg.Payload) == 0 {
 				return slog.Attr{}
 			}
-			return slog.String(fPayload, base64.StdEncoding.EncodeToString(msg.Payload))
+			return slog.String(key, base64.StdEncoding.EncodeToString(msg.Payload))
 		}
 	}
 }
@@ -325,114 +544,127 @@ func PayloadAsBase64() FieldOpt {
 // PayloadAsBase64Always logs the payload of the message as base64 encoded
 // string, even when empty.
 func PayloadAsBase64Always() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fPayload, base64.StdEncoding.EncodeToString(msg.Payload))
+	return func(p *plan) {
+		key := p.key(fPayload)
+		p.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, base64.StdEncoding.EncodeToString(msg.Payload))
 		}
 	}
 }
 
 // PayloadSize logs the size of the payload of the message. Empty payloads are omitted.
 func PayloadSize() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPayloadSize] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fPayloadSize)
+		p.fields[idxPayloadSize] = func(msg wrp.Message) slog.Attr {
 			if len(msg.Payload) == 0 {
 				return slog.Attr{}
 			}
-			return slog.Int(fPayloadSize, len(msg.Payload))
+			return slog.Int(key, len(msg.Payload))
 		}
 	}
 }
 
 // PayloadSizeAlways logs the size of the payload of the message, even when empty.
 func PayloadSizeAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPayloadSize] = func(msg wrp.Message) slog.Attr {
-			return slog.Int(fPayloadSize, len(msg.Payload))
+	return func(p *plan) {
+		key := p.key(fPayloadSize)
+		p.fields[idxPayloadSize] = func(msg wrp.Message) slog.Attr {
+			return slog.Int(key, len(msg.Payload))
 		}
 	}
 }
 
 // ServiceName logs the service name of the message. Empty values are omitted.
 func ServiceName() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxServiceName] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fServiceName)
+		p.fields[idxServiceName] = func(msg wrp.Message) slog.Attr {
 			if msg.ServiceName == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fServiceName, msg.ServiceName)
+			return slog.String(key, msg.ServiceName)
 		}
 	}
 }
 
 // ServiceNameAlways logs the service name of the message, even when empty.
 func ServiceNameAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxServiceName] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fServiceName, msg.ServiceName)
+	return func(p *plan) {
+		key := p.key(fServiceName)
+		p.fields[idxServiceName] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.ServiceName)
 		}
 	}
 }
 
 // URL logs the URL of the message. Empty values are omitted.
 func URL() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxURL] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fURL)
+		redact := p.redactor
+		p.fields[idxURL] = func(msg wrp.Message) slog.Attr {
 			if msg.URL == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fURL, msg.URL)
+			return slog.String(key, redactDeviceIDs(msg.URL, redact))
 		}
 	}
 }
 
 // URLAlways logs the URL of the message, even when empty.
 func URLAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxURL] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fURL, msg.URL)
+	return func(p *plan) {
+		key := p.key(fURL)
+		redact := p.redactor
+		p.fields[idxURL] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, redactDeviceIDs(msg.URL, redact))
 		}
 	}
 }
 
 // PartnerIDs logs the partner IDs of the message. Empty values are omitted.
 func PartnerIDs() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPartnerIDs] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fPartnerIDs)
+		p.fields[idxPartnerIDs] = func(msg wrp.Message) slog.Attr {
 			if len(msg.PartnerIDs) == 0 {
 				return slog.Attr{}
 			}
-			return slog.Any(fPartnerIDs, msg.PartnerIDs)
+			return slog.Any(key, msg.PartnerIDs)
 		}
 	}
 }
 
 // PartnerIDsAlways logs the partner IDs of the message, even when empty.
 func PartnerIDsAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxPartnerIDs] = func(msg wrp.Message) slog.Attr {
-			return slog.Any(fPartnerIDs, msg.PartnerIDs)
+	return func(p *plan) {
+		key := p.key(fPartnerIDs)
+		p.fields[idxPartnerIDs] = func(msg wrp.Message) slog.Attr {
+			return slog.Any(key, msg.PartnerIDs)
 		}
 	}
 }
 
 // SessionID logs the session ID of the message. Empty values are omitted.
 func SessionID() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxSessionID] = func(msg wrp.Message) slog.Attr {
+	return func(p *plan) {
+		key := p.key(fSessionID)
+		p.fields[idxSessionID] = func(msg wrp.Message) slog.Attr {
 			if msg.SessionID == "" {
 				return slog.Attr{}
 			}
-			return slog.String(fSessionID, msg.SessionID)
+			return slog.String(key, msg.SessionID)
 		}
 	}
 }
 
 // SessionIDAlways logs the session ID of the message, even when empty.
 func SessionIDAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxSessionID] = func(msg wrp.Message) slog.Attr {
-			return slog.String(fSessionID, msg.SessionID)
+	return func(p *plan) {
+		key := p.key(fSessionID)
+		p.fields[idxSessionID] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, msg.SessionID)
 		}
 	}
 }
@@ -444,9 +676,69 @@ func QualityOfService() FieldOpt {
 
 // QualityOfServiceAlways logs the quality of service of the message, even when zero.
 func QualityOfServiceAlways() FieldOpt {
-	return func(ob *Observer) {
-		ob.fields[idxQualityOfService] = func(msg wrp.Message) slog.Attr {
-			return slog.Int(fQualityOfService, int(msg.QualityOfService))
+	return func(p *plan) {
+		key := p.key(fQualityOfService)
+		p.fields[idxQualityOfService] = func(msg wrp.Message) slog.Attr {
+			return slog.Int(key, int(msg.QualityOfService))
 		}
 	}
 }
+
+// Field logs a custom field under key using the value returned by fn. The key
+// of the returned slog.Attr is ignored, so fn may return slog.Any("", v).
+// Returning slog.Attr{} or an empty value (nil, including a nil slice, map or
+// pointer, "" or an empty group) omits the field. A Field with an empty key or
+// nil fn is ignored.
+//
+// Custom fields are logged after the built-in fields, in the order configured.
+// New rejects custom fields whose keys are used by another field.
+func Field(key string, fn func(wrp.Message) slog.Attr) FieldOpt {
+	return customField(key, fn, false)
+}
+
+// FieldAlways logs a custom field under key using the value returned by fn,
+// even when the value is empty.
+func FieldAlways(key string, fn func(wrp.Message) slog.Attr) FieldOpt {
+	return customField(key, fn, true)
+}
+
+func customField(name string, fn func(wrp.Message) slog.Attr, always bool) FieldOpt {
+	return func(p *plan) {
+		if name == "" || fn == nil {
+			return
+		}
+		key := p.prefix + name
+		p.names = append(p.names, name)
+		p.custom = append(p.custom, func(msg wrp.Message) slog.Attr {
+			attr := fn(msg)
+			if !always && isEmptyValue(attr.Value) {
+				return slog.Attr{}
+			}
+			return slog.Attr{Key: key, Value: attr.Value}
+		})
+	}
+}
+
+// isEmptyValue reports whether v is a nil, empty string or empty group value.
+// A nil slice, map, pointer or other nillable value held by v is nil as well.
+func isEmptyValue(v slog.Value) bool {
+	switch v.Kind() {
+	case slog.KindAny:
+		a := v.Any()
+		if a == nil {
+			return true
+		}
+		switch rv := reflect.ValueOf(a); rv.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Pointer, reflect.Interface, reflect.Chan, reflect.Func:
+			return rv.IsNil()
+		default:
+			return false
+		}
+	case slog.KindString:
+		return v.String() == ""
+	case slog.KindGroup:
+		return len(v.Group()) == 0
+	default:
+		return false
+	}
+}
@@ -4,7 +4,9 @@ go 1.24
 
 require (
 	github.com/stretchr/testify v1.11.1
+	github.com/tinylib/msgp v1.6.4
 	github.com/xmidt-org/wrp-go/v5 v5.4.3
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -12,6 +14,4 @@ require (
 	github.com/kr/text v0.2.0 // indirect
 	github.com/philhofer/fwd v1.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/tinylib/msgp v1.6.4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -63,8 +63,27 @@ func (h *recordHandler) getAttrs(index int) []slog.Attr {
 	}
 	var attrs []slog.Attr
 	h.records[index].Attrs(func(a slog.Attr) bool {
+		// Inline groups with an empty key, as handlers do.
+		if a.Key == "" && a.Value.Kind() == slog.KindGroup {
+			attrs = append(attrs, a.Value.Group()...)
+			return true
+		}
 		attrs = append(attrs, a)
 		return true
 	})
 	return attrs
 }
+
+// chanHandler is a slog.Handler that sends records to a channel. Unlike
+// recordHandler, it is safe for concurrent use.
+type chanHandler chan slog.Record
+
+func (chanHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h chanHandler) Handle(_ context.Context, r slog.Record) error {
+	h <- r
+	return nil
+}
+
+func (h chanHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h chanHandler) WithGroup(string) slog.Handler      { return h }
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// LevelByType returns a function suitable for Observer.LevelFunc that logs
+// each message type at the level given in levels. Message types missing from
+// levels are logged at fallback, or at slog.LevelInfo if fallback is nil.
+func LevelByType(levels map[wrp.MessageType]slog.Level, fallback slog.Leveler) func(context.Context, wrp.Message) slog.Level {
+	levels = maps.Clone(levels)
+	return func(_ context.Context, msg wrp.Message) slog.Level {
+		if level, found := levels[msg.Type]; found {
+			return level
+		}
+		if fallback == nil {
+			return slog.LevelInfo
+		}
+		return fallback.Level()
+	}
+}
+
+// Threshold maps values at or above Min to Level.
+type Threshold struct {
+	Min   int64
+	Level slog.Level
+}
+
+// Escalation raises the level of messages that report a failure. The level
+// of a message is never lowered, so healthy traffic is logged at its normal
+// level.
+type Escalation struct {
+	// Status is checked against the message Status. Of the thresholds whose
+	// Min is not above the status, the one with the highest Min applies.
+	Status []Threshold
+
+	// DeliveryFailure is the level used when the message has a non-zero
+	// RequestDeliveryResponse.
+	DeliveryFailure slog.Level
+}
+
+// DefaultEscalation returns an Escalation that logs 4xx statuses at Warn,
+// 5xx statuses at Error and delivery failures at Warn.
+func DefaultEscalation() *Escalation {
+	return &Escalation{
+		Status: []Threshold{
+			{Min: 400, Level: slog.LevelWarn},
+			{Min: 500, Level: slog.LevelError},
+		},
+		DeliveryFailure: slog.LevelWarn,
+	}
+}
+
+// level returns the level msg should be escalated to, if any.
+func (e *Escalation) level(msg wrp.Message) (level slog.Level, ok bool) {
+	if e == nil {
+		return level, false
+	}
+
+	if msg.Status != nil {
+		var best *Threshold
+		for i := range e.Status {
+			t := &e.Status[i]
+			if t.Min <= *msg.Status && (best == nil || t.Min > best.Min) {
+				best = t
+			}
+		}
+		if best != nil {
+			level, ok = best.Level, true
+		}
+	}
+
+	if msg.RequestDeliveryResponse != nil && *msg.RequestDeliveryResponse != 0 {
+		if !ok || e.DeliveryFailure > level {
+			level, ok = e.DeliveryFailure, true
+		}
+	}
+
+	return level, ok
+}
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestObserver_LevelByType(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelError, // Ignored when LevelFunc is set
+		Message: "wrp message",
+		LevelFunc: LevelByType(map[wrp.MessageType]slog.Level{
+			wrp.SimpleEventMessageType:           slog.LevelDebug,
+			wrp.SimpleRequestResponseMessageType: slog.LevelInfo,
+			wrp.AuthorizationMessageType:         slog.LevelWarn,
+			wrp.ServiceRegistrationMessageType:   slog.LevelWarn,
+		}, slog.LevelInfo),
+		Fields: []FieldOpt{MessageType()},
+	}
+
+	tests := []struct {
+		msgType  wrp.MessageType
+		expected slog.Level
+		logged   bool
+	}{
+		{msgType: wrp.SimpleEventMessageType, logged: false},
+		{msgType: wrp.SimpleRequestResponseMessageType, expected: slog.LevelInfo, logged: true},
+		{msgType: wrp.AuthorizationMessageType, expected: slog.LevelWarn, logged: true},
+		{msgType: wrp.ServiceRegistrationMessageType, expected: slog.LevelWarn, logged: true},
+		{msgType: wrp.CreateMessageType, expected: slog.LevelInfo, logged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msgType.String(), func(t *testing.T) {
+			handler.records = handler.records[:0]
+
+			ob.ObserveWRP(context.Background(), wrp.Message{Type: tt.msgType})
+
+			if !tt.logged {
+				assert.Empty(t, handler.records)
+				return
+			}
+			require.Len(t, handler.records, 1)
+			assert.Equal(t, tt.expected, handler.records[0].Level)
+		})
+	}
+}
+
+func TestObserver_Escalation(t *testing.T) {
+	status := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name       string
+		level      slog.Level
+		escalation *Escalation
+		msg        wrp.Message
+		expected   slog.Level
+	}{
+		{
+			name:       "healthy",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{Status: status(200)},
+			expected:   slog.LevelInfo,
+		},
+		{
+			name:       "no_status",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{},
+			expected:   slog.LevelInfo,
+		},
+		{
+			name:       "client_error",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{Status: status(404)},
+			expected:   slog.LevelWarn,
+		},
+		{
+			name:       "server_error",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{Status: status(503)},
+			expected:   slog.LevelError,
+		},
+		{
+			name:       "delivery_failure",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{RequestDeliveryResponse: status(1)},
+			expected:   slog.LevelWarn,
+		},
+		{
+			name:       "highest_wins",
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{Status: status(500), RequestDeliveryResponse: status(1)},
+			expected:   slog.LevelError,
+		},
+		{
+			name:       "never_lowered",
+			level:      slog.LevelError,
+			escalation: DefaultEscalation(),
+			msg:        wrp.Message{Status: status(404)},
+			expected:   slog.LevelError,
+		},
+		{
+			name: "custom_thresholds",
+			escalation: &Escalation{
+				Status: []Threshold{
+					{Min: 500, Level: slog.LevelError},
+					{Min: 300, Level: slog.LevelWarn},
+				},
+			},
+			msg:      wrp.Message{Status: status(302)},
+			expected: slog.LevelWarn,
+		},
+		{
+			name:     "disabled",
+			msg:      wrp.Message{Status: status(503)},
+			expected: slog.LevelInfo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRecordHandler(slog.LevelDebug)
+			ob := Observer{
+				Logger:     slog.New(handler),
+				Level:      tt.level,
+				Escalation: tt.escalation,
+				Message:    "wrp message",
+			}
+
+			ob.ObserveWRP(context.Background(), tt.msg)
+
+			require.Len(t, handler.records, 1)
+			assert.Equal(t, tt.expected, handler.records[0].Level)
+		})
+	}
+}
+
+func TestObserver_EscalationEnablesRecord(t *testing.T) {
+	status := int64(500)
+	handler := newRecordHandler(slog.LevelWarn)
+	ob := Observer{
+		Logger:     slog.New(handler),
+		Level:      slog.LevelDebug,
+		Escalation: DefaultEscalation(),
+		Message:    "wrp message",
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+	ob.ObserveWRP(context.Background(), wrp.Message{Status: &status})
+
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, slog.LevelError, handler.records[0].Level)
+}
+
+func TestObserver_LevelVar(t *testing.T) {
+	var level slog.LevelVar
+	level.Set(slog.LevelDebug)
+
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   &level,
+		Message: "wrp message",
+		Fields:  []FieldOpt{MessageType()},
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+	require.Empty(t, handler.records)
+
+	level.Set(slog.LevelInfo)
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, slog.LevelInfo, handler.records[0].Level)
+}
+
+func TestObserver_NilLevel(t *testing.T) {
+	handler := newRecordHandler(slog.LevelDebug)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Message: "wrp message",
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, slog.LevelInfo, handler.records[0].Level)
+}
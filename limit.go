@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// Names of the fields of suppressed message summaries.
+const (
+	fCount = "count"
+	fKey   = "key"
+)
+
+// Limiter defaults.
+const (
+	// DefaultSummaryInterval is how often a Limiter reports suppressed
+	// messages when Interval is not set.
+	DefaultSummaryInterval = 10 * time.Second
+
+	// DefaultMaxKeys is the number of keys a Limiter tracks when MaxKeys is
+	// not set.
+	DefaultMaxKeys = 10000
+
+	// DefaultSuppressedMessage is the log message of suppressed message
+	// summaries when SummaryMessage is not set.
+	DefaultSuppressedMessage = "wrp message suppressed"
+)
+
+// LimitBy selects how a Limiter divides messages between token buckets.
+type LimitBy int
+
+const (
+	// LimitGlobal limits all messages with a single bucket.
+	LimitGlobal LimitBy = iota
+
+	// LimitByDevice gives every device, as logged by DeviceID, its own
+	// bucket. Messages without a device share one bucket.
+	LimitByDevice
+
+	// LimitByType gives every message type its own bucket.
+	LimitByType
+)
+
+// Limiter caps the rate messages are logged at with token buckets. Messages
+// over the limit are dropped and counted, and every Interval one summary
+// record, such as
+//
+//	"wrp message suppressed" count=1234 key=mac:112233445566
+//
+// is logged for each bucket that dropped messages. See Observer.Run.
+//
+// A Limiter must be used as a pointer and must not be copied after first use.
+// Its fields are read on first use. It may be shared by several observers.
+type Limiter struct {
+	// Rate is the number of messages per second each bucket allows.
+	Rate float64
+
+	// Burst is the number of messages each bucket allows at once. Defaults
+	// to Rate rounded up, and at least 1.
+	Burst int
+
+	// By selects how messages are divided between buckets.
+	By LimitBy
+
+	// Interval is how often suppressed messages are reported. Defaults to
+	// DefaultSummaryInterval.
+	Interval time.Duration
+
+	// MaxKeys bounds the number of buckets. Once it is reached, messages
+	// with new keys share a single bucket until idle buckets are dropped.
+	// Defaults to DefaultMaxKeys.
+	MaxKeys int
+
+	// SummaryMessage is the log message of summaries. Defaults to
+	// DefaultSuppressedMessage.
+	SummaryMessage string
+
+	once        sync.Once
+	rate        float64
+	burst       float64
+	by          LimitBy
+	interval    time.Duration
+	maxKeys     int
+	message     string
+	mu          sync.Mutex
+	buckets     map[limitKey]*bucket
+	overflow    bucket
+	lastSummary time.Time
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// limitKey identifies a bucket.
+type limitKey struct {
+	a, b string
+}
+
+// bucket is a token bucket and the number of messages it dropped.
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int64
+
+	// label is logged as the key of summaries.
+	label string
+}
+
+// suppressed is the summary of the messages dropped by a bucket.
+type suppressed struct {
+	count int64
+	label string
+}
+
+func (l *Limiter) init() {
+	l.once.Do(func() {
+		l.rate = l.Rate
+		l.by = l.By
+		l.burst = float64(l.Burst)
+		if l.Burst <= 0 {
+			l.burst = max(1, math.Ceil(l.rate))
+		}
+		l.interval = cmp.Or(max(l.Interval, 0), DefaultSummaryInterval)
+		l.maxKeys = cmp.Or(max(l.MaxKeys, 0), DefaultMaxKeys)
+		l.message = cmp.Or(l.SummaryMessage, DefaultSuppressedMessage)
+		if l.now == nil {
+			l.now = time.Now
+		}
+		l.buckets = make(map[limitKey]*bucket)
+		l.overflow.label = "other"
+		l.lastSummary = l.now()
+	})
+}
+
+// key returns the bucket key of msg.
+func (l *Limiter) key(msg wrp.Message) limitKey {
+	switch l.by {
+	case LimitByDevice:
+		scheme, authority := messageDevice(msg)
+		return limitKey{a: scheme, b: authority}
+	case LimitByType:
+		return limitKey{a: msg.Type.String()}
+	default:
+		return limitKey{}
+	}
+}
+
+// label returns the key logged in the summaries of the bucket for key.
+func (l *Limiter) label(key limitKey, redact Redactor) string {
+	if l.by != LimitByDevice || key.a == "" {
+		return key.a
+	}
+	authority := key.b
+	if redact != nil && isRedactedScheme(key.a) {
+		authority = redact(key.a, authority)
+	}
+	return key.a + ":" + authority
+}
+
+// allow reports whether msg may be logged, and returns the summaries that are
+// due. redact is applied to device IDs logged as keys.
+func (l *Limiter) allow(msg wrp.Message, redact Redactor) (bool, []suppressed) {
+	l.init()
+	key := l.key(msg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.buckets[key]
+	if b == nil {
+		if len(l.buckets) < l.maxKeys {
+			b = &bucket{
+				tokens: l.burst,
+				last:   now,
+				label:  l.label(key, redact),
+			}
+			l.buckets[key] = b
+		} else {
+			b = &l.overflow
+		}
+	}
+
+	ok := b.take(now, l.rate, l.burst)
+
+	var due []suppressed
+	if now.Sub(l.lastSummary) >= l.interval {
+		due = l.summarize(now)
+	}
+	return ok, due
+}
+
+// due returns the summaries of the buckets that dropped messages if Interval
+// has passed since the last summaries.
+func (l *Limiter) due() []suppressed {
+	l.init()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSummary) < l.interval {
+		return nil
+	}
+	return l.summarize(now)
+}
+
+// flush returns the summaries of all buckets that dropped messages, whether
+// or not they are due.
+func (l *Limiter) flush() []suppressed {
+	l.init()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.summarize(l.now())
+}
+
+// summarize returns and resets the counts of the buckets that dropped
+// messages, and drops buckets that are idle. It must be called with mu held.
+func (l *Limiter) summarize(now time.Time) []suppressed {
+	l.lastSummary = now
+
+	var due []suppressed
+	for key, b := range l.buckets {
+		if b.suppressed > 0 {
+			due = append(due, suppressed{count: b.suppressed, label: b.label})
+			b.suppressed = 0
+		} else if b.refill(now, l.rate, l.burst) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	if l.overflow.suppressed > 0 {
+		due = append(due, suppressed{count: l.overflow.suppressed, label: l.overflow.label})
+		l.overflow.suppressed = 0
+	}
+
+	slices.SortFunc(due, func(a, b suppressed) int {
+		return strings.Compare(a.label, b.label)
+	})
+	return due
+}
+
+// take removes a token from the bucket, counting the message as suppressed if
+// there is none.
+func (b *bucket) take(now time.Time, rate, burst float64) bool {
+	if b.refill(now, rate, burst) >= 1 {
+		b.tokens--
+		return true
+	}
+	b.suppressed++
+	return false
+}
+
+// refill adds the tokens earned since the last refill and returns the tokens
+// in the bucket.
+func (b *bucket) refill(now time.Time, rate, burst float64) float64 {
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(burst, b.tokens+elapsed.Seconds()*rate)
+	}
+	b.last = now
+	return b.tokens
+}
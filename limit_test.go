@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// fakeClock is a clock for Limiter tests that only moves when told to.
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// limitedObserver returns an observer that logs the source of messages
+// through limiter, and the handler it logs to.
+func limitedObserver(limiter *Limiter, clock *fakeClock) (*Observer, *recordHandler) {
+	limiter.now = clock.now
+	handler := newRecordHandler(slog.LevelInfo)
+	return &Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source()},
+		Limiter: limiter,
+	}, handler
+}
+
+// summaries returns the count and key of the summary records logged to
+// handler, in order.
+func summaries(handler *recordHandler) [][2]string {
+	var got [][2]string
+	for i, record := range handler.records {
+		if record.Message != DefaultSuppressedMessage {
+			continue
+		}
+		var summary [2]string
+		for _, attr := range handler.getAttrs(i) {
+			switch attr.Key {
+			case fCount:
+				summary[0] = attr.Value.String()
+			case fKey:
+				summary[1] = attr.Value.String()
+			}
+		}
+		got = append(got, summary)
+	}
+	return got
+}
+
+func TestLimiter_Global(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1, Burst: 2}, clock)
+	ctx := context.Background()
+	msg := wrp.Message{Source: "dns:example.com"}
+
+	for range 5 {
+		ob.ObserveWRP(ctx, msg)
+	}
+	assert.Len(t, handler.records, 2, "only the burst is logged")
+
+	clock.advance(time.Second)
+	ob.ObserveWRP(ctx, msg)
+	ob.ObserveWRP(ctx, msg)
+	assert.Len(t, handler.records, 3, "one token is earned per second")
+	assert.Empty(t, summaries(handler), "summaries wait for the interval")
+
+	clock.advance(DefaultSummaryInterval)
+	ob.ObserveWRP(ctx, msg)
+	require.Len(t, handler.records, 5)
+	assert.Equal(t, [][2]string{{"4", ""}}, summaries(handler))
+	assert.Equal(t, slog.LevelInfo, handler.records[3].Level)
+	assert.Equal(t, "wrp message", handler.records[4].Message)
+
+	clock.advance(DefaultSummaryInterval)
+	ob.ObserveWRP(ctx, msg)
+	assert.Len(t, summaries(handler), 1, "nothing was suppressed since the last summary")
+}
+
+func TestLimiter_ByDevice(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1, By: LimitByDevice}, clock)
+	ob.Redactor = MaskRedactor()
+	ctx := context.Background()
+
+	for range 3 {
+		ob.ObserveWRP(ctx, wrp.Message{Source: "mac:112233445566"})
+		ob.ObserveWRP(ctx, wrp.Message{Source: "dns:talaria.example.com", Destination: "MAC:11:22:33:44:55:66"})
+		ob.ObserveWRP(ctx, wrp.Message{Source: "uuid:abc"})
+		ob.ObserveWRP(ctx, wrp.Message{Source: "event:device-status"})
+	}
+	assert.Len(t, handler.records, 3, "one message per device, and one without a device")
+
+	ob.Flush(ctx)
+	assert.Equal(t, [][2]string{
+		{"2", ""},
+		{"5", "mac:************"},
+		{"2", "uuid:***"},
+	}, summaries(handler))
+
+	ob.Flush(ctx)
+	assert.Len(t, summaries(handler), 3, "flushed counts are reset")
+}
+
+func TestLimiter_ByType(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1, By: LimitByType}, clock)
+	ctx := context.Background()
+
+	for range 2 {
+		ob.ObserveWRP(ctx, wrp.Message{Type: wrp.SimpleEventMessageType, Source: "dns:example.com"})
+		ob.ObserveWRP(ctx, wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Source: "dns:example.com"})
+	}
+	assert.Len(t, handler.records, 2)
+
+	ob.Flush(ctx)
+	assert.Equal(t, [][2]string{
+		{"1", wrp.SimpleEventMessageType.String()},
+		{"1", wrp.SimpleRequestResponseMessageType.String()},
+	}, summaries(handler))
+}
+
+func TestLimiter_MaxKeys(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1, By: LimitByDevice, MaxKeys: 1}, clock)
+	ctx := context.Background()
+
+	ob.ObserveWRP(ctx, wrp.Message{Source: "mac:112233445566"})
+	ob.ObserveWRP(ctx, wrp.Message{Source: "mac:aabbccddeeff"})
+	ob.ObserveWRP(ctx, wrp.Message{Source: "mac:665544332211"})
+	assert.Len(t, handler.records, 2, "devices over the limit share a bucket")
+
+	ob.Flush(ctx)
+	assert.Equal(t, [][2]string{{"1", "other"}}, summaries(handler))
+
+	// Once the first device's bucket is idle it is dropped, making room for
+	// another device.
+	clock.advance(time.Minute)
+	ob.Flush(ctx)
+	ob.ObserveWRP(ctx, wrp.Message{Source: "mac:aabbccddeeff"})
+	ob.ObserveWRP(ctx, wrp.Message{Source: "mac:aabbccddeeff"})
+	ob.Flush(ctx)
+	assert.Equal(t, [][2]string{{"1", "other"}, {"1", "mac:aabbccddeeff"}}, summaries(handler))
+}
+
+func TestLimiter_SummaryKeys(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1, By: LimitByType, SummaryMessage: "dropped"}, clock)
+	ob.KeyPrefix = "wrp_"
+	ob.Group = "wrp"
+	ctx := context.Background()
+
+	ob.ObserveWRP(ctx, wrp.Message{Type: wrp.SimpleEventMessageType})
+	ob.ObserveWRP(ctx, wrp.Message{Type: wrp.SimpleEventMessageType})
+	ob.Flush(ctx)
+
+	require.Len(t, handler.records, 2)
+	record := handler.records[1]
+	assert.Equal(t, "dropped", record.Message)
+
+	var attrs []slog.Attr
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	require.Len(t, attrs, 1)
+	assert.Equal(t, "wrp", attrs[0].Key)
+	group := attrs[0].Value.Group()
+	require.Len(t, group, 2)
+	assert.True(t, slog.Int64("wrp_count", 1).Equal(group[0]))
+	assert.True(t, slog.String("wrp_key", wrp.SimpleEventMessageType.String()).Equal(group[1]))
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	clock := newFakeClock()
+	ob, handler := limitedObserver(&Limiter{Rate: 1}, clock)
+	ob.Level = slog.LevelDebug
+
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+	ob.ObserveWRP(context.Background(), wrp.Message{})
+	ob.Flush(context.Background())
+	assert.Empty(t, handler.records)
+
+	var nilLogger Observer
+	nilLogger.Flush(context.Background())
+}
+
+func TestLimiter_Run(t *testing.T) {
+	records := make(chanHandler, 10)
+	ob := Observer{
+		Logger:  slog.New(records),
+		Message: "wrp message",
+		Limiter: &Limiter{Rate: 1, Interval: 10 * time.Millisecond},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for range 3 {
+		ob.ObserveWRP(ctx, wrp.Message{})
+	}
+	require.Equal(t, "wrp message", (<-records).Message)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ob.Run(ctx)
+	}()
+
+	// The summary is logged without waiting for another message.
+	select {
+	case record := <-records:
+		assert.Equal(t, DefaultSuppressedMessage, record.Message)
+		assert.Equal(t, 1, record.NumAttrs())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no summary was logged")
+	}
+
+	// Messages dropped since are flushed when ctx is done.
+	ob.ObserveWRP(ctx, wrp.Message{})
+	cancel()
+	<-done
+	require.Len(t, records, 1)
+	assert.Equal(t, DefaultSuppressedMessage, (<-records).Message)
+}
+
+func TestLimiter_Concurrent(t *testing.T) {
+	limiter := Limiter{Rate: 1, Burst: 100}
+	ob, _ := limitedObserver(&limiter, newFakeClock())
+	// recordHandler is not safe for concurrent use.
+	ob.Logger = slog.New(discardHandler{})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				ob.ObserveWRP(context.Background(), wrp.Message{Source: "mac:112233445566"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	due := limiter.flush()
+	require.Len(t, due, 1)
+	assert.Equal(t, int64(700), due[0].count)
+}
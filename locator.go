@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// Names of the fields and sub-attributes derived from locators.
+const (
+	fScheme       = "scheme"
+	fAuthority    = "authority"
+	fService      = "service"
+	fIgnored      = "ignored"
+	fParseError   = "parse_error"
+	fDeviceID     = "device_id"
+	fEventType    = "event_type"
+	fEventSubpath = "event_subpath"
+)
+
+// Locator schemes that are inspected when looking for a device ID.
+const (
+	schemeMAC    = "mac"
+	schemeUUID   = "uuid"
+	schemeSerial = "serial"
+	schemeDNS    = "dns"
+	schemeEvent  = "event"
+)
+
+// SourceLocator logs the source of the message parsed as a WRP locator. The
+// value is a group containing the scheme, authority, service and ignored
+// parts; empty parts are omitted. Uses the same slot as Source/SourceAlways.
+//
+// Empty values are omitted. Malformed locators are logged as a group holding
+// only a parse_error attribute.
+func SourceLocator() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fSource)
+		redact := p.redactor
+		p.fields[idxSource] = func(msg wrp.Message) slog.Attr {
+			return locatorAttr(key, msg.Source, redact)
+		}
+	}
+}
+
+// DestinationLocator logs the destination of the message parsed as a WRP
+// locator. Uses the same slot as Destination/DestinationAlways.
+//
+// See SourceLocator for the logged format.
+func DestinationLocator() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fDestination)
+		redact := p.redactor
+		p.fields[idxDestination] = func(msg wrp.Message) slog.Attr {
+			return locatorAttr(key, msg.Destination, redact)
+		}
+	}
+}
+
+// locatorAttr parses locator and returns it as a group under key.
+func locatorAttr(key, locator string, redact Redactor) slog.Attr {
+	if locator == "" {
+		return slog.Attr{}
+	}
+
+	l, err := wrp.ParseLocator(locator)
+	if err != nil {
+		return slog.Group(key, slog.String(fParseError, err.Error()))
+	}
+
+	authority := l.Authority
+	if redact != nil {
+		scheme := strings.ToLower(l.Scheme)
+		if isRedactedScheme(scheme) {
+			authority = redact(scheme, deviceAuthority(scheme, authority))
+		}
+	}
+
+	attrs := make([]slog.Attr, 0, 4)
+	for _, part := range [...]slog.Attr{
+		slog.String(fScheme, l.Scheme),
+		slog.String(fAuthority, authority),
+		slog.String(fService, redactDeviceIDs(l.Service, redact)),
+		slog.String(fIgnored, redactDeviceIDs(l.Ignored, redact)),
+	} {
+		if part.Value.String() != "" {
+			attrs = append(attrs, part)
+		}
+	}
+
+	return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+}
+
+// DeviceID logs the device ID found in the source or destination of the
+// message. Device-style locators (mac:, uuid:, serial:) are preferred over
+// dns: locators, and the source is checked before the destination. The device
+// embedded in an event: locator (e.g. event:device-status/mac:112233445566/online)
+// is also recognized.
+//
+// The ID is normalized: the scheme is lowercased, MAC addresses are lowercased
+// with separators removed (mac:112233445566), and uuid: and dns: IDs are
+// lowercased. Messages without a device ID are omitted.
+func DeviceID() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fDeviceID)
+		redact := p.redactor
+		p.fields[idxDeviceID] = func(msg wrp.Message) slog.Attr {
+			id := deviceID(msg, redact)
+			if id == "" {
+				return slog.Attr{}
+			}
+			return slog.String(key, id)
+		}
+	}
+}
+
+// DeviceIDAlways logs the device ID of the message, even when none is found.
+func DeviceIDAlways() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fDeviceID)
+		redact := p.redactor
+		p.fields[idxDeviceID] = func(msg wrp.Message) slog.Attr {
+			return slog.String(key, deviceID(msg, redact))
+		}
+	}
+}
+
+// deviceID returns the normalized device ID of msg, or "" if there is none.
+func deviceID(msg wrp.Message, redact Redactor) string {
+	scheme, authority := messageDevice(msg)
+	if scheme == "" {
+		return ""
+	}
+	if redact != nil && isRedactedScheme(scheme) {
+		authority = redact(scheme, authority)
+	}
+	return scheme + ":" + authority
+}
+
+// messageDevice returns the scheme and normalized authority of the device
+// that sent or receives msg, preferring a mac:, uuid: or serial: locator to a
+// dns: one. Both are empty if neither locator identifies a device.
+func messageDevice(msg wrp.Message) (scheme, authority string) {
+	for _, locator := range [...]string{msg.Source, msg.Destination} {
+		s, a := findDeviceID(locator)
+		if s == schemeDNS && scheme == "" {
+			scheme, authority = s, a
+		} else if s != "" && s != schemeDNS {
+			return s, a
+		}
+	}
+	return scheme, authority
+}
+
+// findDeviceID returns the scheme and normalized authority of the device
+// identified by locator. Both are empty if locator does not identify a device.
+func findDeviceID(locator string) (string, string) {
+	if locator == "" {
+		return "", ""
+	}
+
+	l, err := wrp.ParseLocator(locator)
+	if err != nil {
+		return "", ""
+	}
+
+	scheme := strings.ToLower(l.Scheme)
+	if scheme == schemeEvent {
+		if _, path, found := strings.Cut(locator, "/"); found {
+			return findDeviceID(path)
+		}
+		return "", ""
+	}
+
+	authority := deviceAuthority(scheme, l.Authority)
+	if authority == "" {
+		return "", ""
+	}
+	return scheme, authority
+}
+
+// deviceAuthority returns the canonical form of the authority of a device
+// locator, or "" if the scheme does not identify a device.
+func deviceAuthority(scheme, authority string) string {
+	if authority == "" {
+		return ""
+	}
+
+	switch scheme {
+	case schemeMAC:
+		return normalizeMAC(authority)
+	case schemeUUID, schemeDNS:
+		return strings.ToLower(authority)
+	case schemeSerial:
+		return authority
+	default:
+		return ""
+	}
+}
+
+// normalizeMAC lowercases a MAC address and removes any separators.
+func normalizeMAC(mac string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.', ',':
+			return -1
+		}
+		if 'A' <= r && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, mac)
+}
+
+// EventType logs the event type of an event: destination, e.g. device-status
+// for event:device-status/mac:112233445566/online. Messages without an event:
+// destination are omitted.
+func EventType() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fEventType)
+		p.fields[idxEventType] = func(msg wrp.Message) slog.Attr {
+			eventType, _ := eventParts(msg.Destination)
+			if eventType == "" {
+				return slog.Attr{}
+			}
+			return slog.String(key, eventType)
+		}
+	}
+}
+
+// EventSubpath logs the part of an event: destination following the event
+// type, e.g. mac:112233445566/online for
+// event:device-status/mac:112233445566/online. Empty values are omitted.
+func EventSubpath() FieldOpt {
+	return func(p *plan) {
+		key := p.key(fEventSubpath)
+		redact := p.redactor
+		p.fields[idxEventSubpath] = func(msg wrp.Message) slog.Attr {
+			_, subpath := eventParts(msg.Destination)
+			if subpath == "" {
+				return slog.Attr{}
+			}
+			return slog.String(key, redactDeviceIDs(subpath, redact))
+		}
+	}
+}
+
+// eventParts splits an event: locator into its event type and subpath. Both
+// are empty if locator does not use the event scheme.
+func eventParts(locator string) (eventType, subpath string) {
+	scheme, rest, found := strings.Cut(locator, ":")
+	if !found || !strings.EqualFold(scheme, schemeEvent) {
+		return "", ""
+	}
+
+	eventType, subpath, _ = strings.Cut(rest, "/")
+	return eventType, subpath
+}
@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// observeOne runs msg through an observer configured with fields and returns
+// the attributes of the single record logged.
+func observeOne(t *testing.T, msg wrp.Message, fields ...FieldOpt) []slog.Attr {
+	t.Helper()
+
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  fields,
+	}
+
+	ob.ObserveWRP(context.Background(), msg)
+
+	require.Len(t, handler.records, 1)
+	return handler.getAttrs(0)
+}
+
+func TestLocatorFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []FieldOpt
+		msg      wrp.Message
+		expected []slog.Attr
+	}{
+		{
+			name:   "source",
+			fields: []FieldOpt{SourceLocator()},
+			msg:    wrp.Message{Source: "mac:112233445566/config"},
+			expected: []slog.Attr{
+				slog.Group(fSource,
+					slog.String(fScheme, "mac"),
+					slog.String(fAuthority, "112233445566"),
+					slog.String(fService, "config"),
+				),
+			},
+		},
+		{
+			name:   "destination",
+			fields: []FieldOpt{DestinationLocator()},
+			msg:    wrp.Message{Destination: "dns:talaria.example.com"},
+			expected: []slog.Attr{
+				slog.Group(fDestination,
+					slog.String(fScheme, "dns"),
+					slog.String(fAuthority, "talaria.example.com"),
+				),
+			},
+		},
+		{
+			name:     "empty_omitted",
+			fields:   []FieldOpt{SourceLocator(), DestinationLocator()},
+			msg:      wrp.Message{},
+			expected: nil,
+		},
+		{
+			name:   "same_slot_as_source",
+			fields: []FieldOpt{SourceLocator(), Source()},
+			msg:    wrp.Message{Source: "mac:112233445566"},
+			expected: []slog.Attr{
+				slog.String(fSource, "mac:112233445566"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := observeOne(t, tt.msg, tt.fields...)
+			require.Len(t, attrs, len(tt.expected))
+			for i, attr := range attrs {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestLocatorFields_Malformed(t *testing.T) {
+	attrs := observeOne(t, wrp.Message{Source: "not a locator"}, SourceLocator())
+
+	require.Len(t, attrs, 1)
+	assert.Equal(t, fSource, attrs[0].Key)
+
+	group := attrs[0].Value.Group()
+	require.Len(t, group, 1)
+	assert.Equal(t, fParseError, group[0].Key)
+	assert.NotEmpty(t, group[0].Value.String())
+}
+
+func TestDeviceID(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		expected    string
+	}{
+		{
+			name:        "mac_source",
+			source:      "mac:112233445566/config",
+			destination: "dns:talaria.example.com",
+			expected:    "mac:112233445566",
+		},
+		{
+			name:        "mac_destination",
+			source:      "dns:scytale.example.com",
+			destination: "mac:112233445566/config",
+			expected:    "mac:112233445566",
+		},
+		{
+			name:     "mac_normalized",
+			source:   "MAC:11:22:33:AA:BB:CC/config",
+			expected: "mac:112233aabbcc",
+		},
+		{
+			name:     "uuid_lowercased",
+			source:   "uuid:ABC-123",
+			expected: "uuid:abc-123",
+		},
+		{
+			name:        "serial",
+			destination: "serial:ABC123/service",
+			expected:    "serial:ABC123",
+		},
+		{
+			name:        "event_destination",
+			source:      "dns:talaria.example.com",
+			destination: "event:device-status/mac:112233445566/online",
+			expected:    "mac:112233445566",
+		},
+		{
+			name:     "dns_fallback",
+			source:   "dns:Device.Example.com",
+			expected: "dns:device.example.com",
+		},
+		{
+			name:        "none",
+			source:      "self:/service",
+			destination: "not a locator",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := wrp.Message{Source: tt.source, Destination: tt.destination}
+
+			attrs := observeOne(t, msg, DeviceID())
+			if tt.expected == "" {
+				assert.Empty(t, attrs)
+			} else {
+				require.Len(t, attrs, 1)
+				assert.True(t, slog.String(fDeviceID, tt.expected).Equal(attrs[0]), "got %v", attrs[0])
+			}
+
+			attrs = observeOne(t, msg, DeviceIDAlways())
+			require.Len(t, attrs, 1)
+			assert.True(t, slog.String(fDeviceID, tt.expected).Equal(attrs[0]), "got %v", attrs[0])
+		})
+	}
+}
+
+func TestEventType(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		expected    []slog.Attr
+	}{
+		{
+			name:        "type_and_subpath",
+			destination: "event:device-status/mac:112233445566/online",
+			expected: []slog.Attr{
+				slog.String(fEventType, "device-status"),
+				slog.String(fEventSubpath, "mac:112233445566/online"),
+			},
+		},
+		{
+			name:        "type_only",
+			destination: "EVENT:node-change",
+			expected: []slog.Attr{
+				slog.String(fEventType, "node-change"),
+			},
+		},
+		{
+			name:        "not_an_event",
+			destination: "mac:112233445566/config",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := wrp.Message{Type: wrp.SimpleEventMessageType, Destination: tt.destination}
+
+			attrs := observeOne(t, msg, EventType(), EventSubpath())
+			require.Len(t, attrs, len(tt.expected))
+			for i, attr := range attrs {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
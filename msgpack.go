@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/tinylib/msgp/msgp"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// Default limits used by PayloadMsgpack.
+const (
+	defaultMsgpackDepth   = 4
+	defaultMsgpackEntries = 64
+	defaultMsgpackBytes   = 4096
+)
+
+// PayloadMsgpack logs a msgpack payload, such as a nested WRP message, decoded
+// into a group. Maps are logged as groups keyed by their keys, arrays as
+// groups keyed by index and binary values base64 encoded. Number and boolean
+// map keys are formatted as text; entries with other keys, such as maps, are
+// skipped.
+//
+// maxDepth limits how deeply maps and arrays are decoded, maxEntries limits
+// the total number of map and array entries logged and maxBytes limits the
+// total length of the strings, binary values and map keys logged; zero or
+// less uses a default of 4, 64 and 4096 respectively. Containers nested
+// deeper than maxDepth are logged as a placeholder such as map[3], values
+// over maxBytes are cut short and entries after it is used up are skipped.
+// When any limit is hit or an entry is skipped, payload_truncated=true is
+// logged as well. Device identifiers in strings and map keys are redacted by
+// the Redactor. Uses the same slot as PayloadAsBase64.
+//
+// Only payloads with a msgpack content type are decoded. A payload that fails
+// to decode is logged as a payload_error attribute instead.
+func PayloadMsgpack(maxDepth, maxEntries, maxBytes int) FieldOpt {
+	if maxDepth <= 0 {
+		maxDepth = defaultMsgpackDepth
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultMsgpackEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultMsgpackBytes
+	}
+
+	return func(p *plan) {
+		key, truncatedKey, errorKey := p.key(fPayload), p.key(fPayloadTruncated), p.key(fPayloadError)
+		redact := p.redactor
+		p.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Payload) == 0 || !isMsgpackContentType(msg.ContentType) {
+				return slog.Attr{}
+			}
+
+			d := msgpackDecoder{
+				maxDepth:  maxDepth,
+				remaining: maxEntries,
+				bytes:     maxBytes,
+				redact:    redact,
+			}
+			v, _, err := d.value(msg.Payload, 0)
+			if err != nil {
+				return slog.String(errorKey, err.Error())
+			}
+
+			if !d.truncated {
+				return slog.Attr{Key: key, Value: v}
+			}
+			return slog.Attr{Value: slog.GroupValue(
+				slog.Attr{Key: key, Value: v},
+				slog.Bool(truncatedKey, true),
+			)}
+		}
+	}
+}
+
+// isMsgpackContentType reports whether contentType describes a msgpack
+// payload.
+func isMsgpackContentType(contentType string) bool {
+	switch mt := mediaType(contentType); mt {
+	case "application/msgpack", "application/x-msgpack", "application/vnd.msgpack":
+		return true
+	default:
+		return strings.HasSuffix(mt, "+msgpack")
+	}
+}
+
+// msgpackDecoder decodes msgpack into slog values within fixed limits.
+type msgpackDecoder struct {
+	maxDepth  int
+	remaining int
+	bytes     int
+	truncated bool
+	redact    Redactor
+}
+
+// value decodes the next msgpack object in b, returning it and the remaining
+// bytes.
+func (d *msgpackDecoder) value(b []byte, depth int) (slog.Value, []byte, error) {
+	switch t := msgp.NextType(b); t {
+	case msgp.MapType:
+		return d.container(b, depth, true)
+	case msgp.ArrayType:
+		return d.container(b, depth, false)
+	case msgp.StrType:
+		s, o, err := msgp.ReadStringZC(b)
+		return slog.StringValue(d.text(s)), o, err
+	case msgp.BinType:
+		v, o, err := msgp.ReadBytesZC(b)
+		return slog.StringValue(d.binary(v)), o, err
+	case msgp.IntType:
+		i, o, err := msgp.ReadInt64Bytes(b)
+		return slog.Int64Value(i), o, err
+	case msgp.UintType:
+		u, o, err := msgp.ReadUint64Bytes(b)
+		return slog.Uint64Value(u), o, err
+	case msgp.Float32Type:
+		f, o, err := msgp.ReadFloat32Bytes(b)
+		return slog.Float64Value(float64(f)), o, err
+	case msgp.Float64Type:
+		f, o, err := msgp.ReadFloat64Bytes(b)
+		return slog.Float64Value(f), o, err
+	case msgp.BoolType:
+		v, o, err := msgp.ReadBoolBytes(b)
+		return slog.BoolValue(v), o, err
+	case msgp.NilType:
+		o, err := msgp.ReadNilBytes(b)
+		return slog.AnyValue(nil), o, err
+	default:
+		o, err := msgp.Skip(b)
+		return slog.StringValue(t.String()), o, err
+	}
+}
+
+// container decodes a map or array into a group value.
+func (d *msgpackDecoder) container(b []byte, depth int, isMap bool) (slog.Value, []byte, error) {
+	var sz uint32
+	var err error
+	if isMap {
+		sz, b, err = msgp.ReadMapHeaderBytes(b)
+	} else {
+		sz, b, err = msgp.ReadArrayHeaderBytes(b)
+	}
+	if err != nil {
+		return slog.Value{}, b, err
+	}
+
+	if depth >= d.maxDepth {
+		d.truncated = true
+		b, err = d.skip(b, sz, isMap)
+		return slog.StringValue(placeholder(sz, isMap)), b, err
+	}
+
+	attrs := make([]slog.Attr, 0, min(int(sz), d.remaining))
+	for i := uint32(0); i < sz; i++ {
+		if d.remaining <= 0 || d.bytes <= 0 {
+			d.truncated = true
+			b, err = d.skip(b, sz-i, isMap)
+			return slog.GroupValue(attrs...), b, err
+		}
+
+		key := strconv.FormatUint(uint64(i), 10)
+		if isMap {
+			var ok bool
+			if key, ok, b, err = d.key(b); err != nil {
+				return slog.Value{}, b, err
+			}
+			if !ok {
+				d.truncated = true
+				if b, err = msgp.Skip(b); err != nil {
+					return slog.Value{}, b, err
+				}
+				continue
+			}
+		}
+
+		var v slog.Value
+		if v, b, err = d.value(b, depth+1); err != nil {
+			return slog.Value{}, b, err
+		}
+
+		d.remaining--
+		attrs = append(attrs, slog.Attr{Key: key, Value: v})
+	}
+
+	return slog.GroupValue(attrs...), b, nil
+}
+
+// key decodes the next map key in b. Strings and binary keys are logged as
+// text and other scalar keys are formatted; ok is false for a key that cannot
+// be logged, such as a map, which is skipped.
+func (d *msgpackDecoder) key(b []byte) (key string, ok bool, o []byte, err error) {
+	switch msgp.NextType(b) {
+	case msgp.StrType, msgp.BinType:
+		var k []byte
+		k, o, err = msgp.ReadMapKeyZC(b)
+		return d.text(k), true, o, err
+	case msgp.IntType:
+		var i int64
+		i, o, err = msgp.ReadInt64Bytes(b)
+		return strconv.FormatInt(i, 10), true, o, err
+	case msgp.UintType:
+		var u uint64
+		u, o, err = msgp.ReadUint64Bytes(b)
+		return strconv.FormatUint(u, 10), true, o, err
+	case msgp.Float32Type, msgp.Float64Type:
+		var f float64
+		f, o, err = msgp.ReadFloat64Bytes(b)
+		return strconv.FormatFloat(f, 'g', -1, 64), true, o, err
+	case msgp.BoolType:
+		var v bool
+		v, o, err = msgp.ReadBoolBytes(b)
+		return strconv.FormatBool(v), true, o, err
+	default:
+		o, err = msgp.Skip(b)
+		return "", false, o, err
+	}
+}
+
+// text returns s as a string with device identifiers redacted, cut short to
+// the bytes left to log.
+func (d *msgpackDecoder) text(s []byte) string {
+	if len(s) > d.bytes {
+		s = trimPartialRune(s[:d.bytes])
+		d.truncated = true
+	}
+	d.bytes -= len(s)
+	return redactDeviceIDs(string(s), d.redact)
+}
+
+// binary returns v base64 encoded, cut short so that the encoding fits in the
+// bytes left to log.
+func (d *msgpackDecoder) binary(v []byte) string {
+	if n := d.bytes / 4 * 3; len(v) > n {
+		v = v[:n]
+		d.truncated = true
+	}
+	s := base64.StdEncoding.EncodeToString(v)
+	d.bytes -= len(s)
+	return s
+}
+
+// skip skips n map entries or array elements.
+func (d *msgpackDecoder) skip(b []byte, n uint32, isMap bool) ([]byte, error) {
+	objects := 1
+	if isMap {
+		// A map entry is a key and a value. Skipping them one entry at a time
+		// keeps n from overflowing.
+		objects = 2
+	}
+
+	var err error
+	for i := uint32(0); i < n; i++ {
+		for range objects {
+			if b, err = msgp.Skip(b); err != nil {
+				return b, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// placeholder describes a container that was not decoded, e.g. map[3].
+func placeholder(sz uint32, isMap bool) string {
+	kind := "array["
+	if isMap {
+		kind = "map["
+	}
+	return kind + strconv.FormatUint(uint64(sz), 10) + "]"
+}
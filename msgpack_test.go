@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestPayloadMsgpack(t *testing.T) {
+	// A WRP event carrying a msgpack payload of its own.
+	var inner []byte
+	inner = msgp.AppendMapHeader(inner, 1)
+	inner = msgp.AppendString(inner, "status")
+	inner = msgp.AppendString(inner, "online")
+
+	var nested []byte
+	nested = msgp.AppendMapHeader(nested, 5)
+	nested = msgp.AppendString(nested, "msg_type")
+	nested = msgp.AppendInt(nested, 4)
+	nested = msgp.AppendString(nested, "source")
+	nested = msgp.AppendString(nested, "mac:112233445566")
+	nested = msgp.AppendString(nested, "partner_ids")
+	nested = msgp.AppendArrayHeader(nested, 2)
+	nested = msgp.AppendString(nested, "comcast")
+	nested = msgp.AppendString(nested, "sky")
+	nested = msgp.AppendString(nested, "ready")
+	nested = msgp.AppendBool(nested, true)
+	nested = msgp.AppendString(nested, "payload")
+	nested = msgp.AppendBytes(nested, inner)
+
+	var deep []byte
+	deep = msgp.AppendArrayHeader(deep, 2)
+	deep = msgp.AppendFloat64(deep, 1.5)
+	deep = msgp.AppendArrayHeader(deep, 1)
+	deep = msgp.AppendMapHeader(deep, 1)
+	deep = msgp.AppendString(deep, "a")
+	deep = msgp.AppendNil(deep)
+
+	var keyed []byte
+	keyed = msgp.AppendMapHeader(keyed, 6)
+	keyed = msgp.AppendInt(keyed, -5)
+	keyed = msgp.AppendString(keyed, "int")
+	keyed = msgp.AppendUint(keyed, 7)
+	keyed = msgp.AppendString(keyed, "uint")
+	keyed = msgp.AppendFloat32(keyed, 1.5)
+	keyed = msgp.AppendString(keyed, "float")
+	keyed = msgp.AppendBool(keyed, true)
+	keyed = msgp.AppendString(keyed, "bool")
+	keyed = msgp.AppendArrayHeader(keyed, 1)
+	keyed = msgp.AppendInt(keyed, 1)
+	keyed = msgp.AppendString(keyed, "array")
+	keyed = msgp.AppendString(keyed, "str")
+	keyed = msgp.AppendString(keyed, "string")
+
+	tests := []struct {
+		name        string
+		maxDepth    int
+		maxEntries  int
+		maxBytes    int
+		contentType string
+		payload     []byte
+		expected    []slog.Attr
+	}{
+		{
+			name:        "nested_wrp",
+			contentType: "application/msgpack",
+			payload:     nested,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.Int64("msg_type", 4),
+					slog.String("source", "mac:112233445566"),
+					slog.Group("partner_ids",
+						slog.String("0", "comcast"),
+						slog.String("1", "sky"),
+					),
+					slog.Bool("ready", true),
+					slog.String("payload", base64.StdEncoding.EncodeToString(inner)),
+				),
+			},
+		},
+		{
+			name:        "entries_limited",
+			maxEntries:  3,
+			contentType: "application/x-msgpack",
+			payload:     nested,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.Int64("msg_type", 4),
+					slog.String("source", "mac:112233445566"),
+					slog.Group("partner_ids",
+						slog.String("0", "comcast"),
+					),
+				),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "depth_limited",
+			maxDepth:    2,
+			contentType: "application/msgpack",
+			payload:     deep,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.Float64("0", 1.5),
+					slog.Group("1",
+						slog.String("0", "map[1]"),
+					),
+				),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "bytes_limited",
+			maxBytes:    24,
+			contentType: "application/msgpack",
+			payload:     nested,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.Int64("msg_type", 4),
+					slog.String("source", "mac:112233"),
+				),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "long_string",
+			maxBytes:    5,
+			contentType: "application/msgpack",
+			payload:     msgp.AppendString(nil, "héllo wörld"),
+			expected: []slog.Attr{
+				slog.String(fPayload, "héll"),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "long_binary",
+			maxBytes:    10,
+			contentType: "application/msgpack",
+			payload:     msgp.AppendBytes(nil, []byte("0123456789")),
+			expected: []slog.Attr{
+				slog.String(fPayload, base64.StdEncoding.EncodeToString([]byte("012345"))),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "scalar_keys",
+			contentType: "application/msgpack",
+			payload:     keyed,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.String("-5", "int"),
+					slog.String("7", "uint"),
+					slog.String("1.5", "float"),
+					slog.String("true", "bool"),
+					slog.String("str", "string"),
+				),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "scalar",
+			contentType: "application/msgpack",
+			payload:     msgp.AppendString(nil, "online"),
+			expected:    []slog.Attr{slog.String(fPayload, "online")},
+		},
+		{
+			name:        "invalid",
+			contentType: "application/msgpack",
+			payload:     nested[:len(nested)-3],
+			expected:    []slog.Attr{slog.String(fPayloadError, msgp.ErrShortBytes.Error())},
+		},
+		{
+			name:        "hostile_header",
+			contentType: "application/msgpack",
+			payload:     msgp.AppendArrayHeader(nil, 1<<31),
+			expected:    []slog.Attr{slog.String(fPayloadError, msgp.ErrShortBytes.Error())},
+		},
+		{
+			// 2^31 entries doubled for keys and values overflows uint32.
+			name:        "hostile_map_header_skipped",
+			maxDepth:    1,
+			contentType: "application/msgpack",
+			payload:     msgp.AppendMapHeader(msgp.AppendArrayHeader(nil, 1), 1<<31),
+			expected:    []slog.Attr{slog.String(fPayloadError, msgp.ErrShortBytes.Error())},
+		},
+		{
+			name:        "not_msgpack",
+			contentType: "application/json",
+			payload:     nested,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := wrp.Message{ContentType: tt.contentType, Payload: tt.payload}
+
+			got := observeOne(t, msg, PayloadMsgpack(tt.maxDepth, tt.maxEntries, tt.maxBytes))
+			require.Len(t, got, len(tt.expected))
+			for i, attr := range got {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestPayloadMsgpack_Redacted(t *testing.T) {
+	var payload []byte
+	payload = msgp.AppendMapHeader(payload, 2)
+	payload = msgp.AppendString(payload, "source")
+	payload = msgp.AppendString(payload, "mac:112233445566")
+	payload = msgp.AppendString(payload, "mac:665544332211")
+	payload = msgp.AppendString(payload, "online")
+
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:   slog.New(handler),
+		Level:    slog.LevelInfo,
+		Message:  "wrp message",
+		Fields:   []FieldOpt{PayloadMsgpack(0, 0, 0)},
+		Redactor: MaskRedactor(),
+	}
+	ob.ObserveWRP(context.Background(), wrp.Message{ContentType: "application/msgpack", Payload: payload})
+
+	require.Len(t, handler.records, 1)
+	expected := slog.Group(fPayload,
+		slog.String("source", "mac:************"),
+		slog.String("mac:************", "online"),
+	)
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 1)
+	assert.True(t, expected.Equal(attrs[0]), "expected %v, got %v", expected, attrs[0])
+}
+
+func TestPayloadMsgpack_Spec(t *testing.T) {
+	opts, err := ParseFields("payload:msgpack[0,0,4]")
+	require.NoError(t, err)
+
+	msg := wrp.Message{
+		ContentType: "application/msgpack",
+		Payload:     msgp.AppendString(nil, strings.Repeat("x", 5<<20)),
+	}
+	got := observeOne(t, msg, opts...)
+	require.Len(t, got, 2)
+	assert.True(t, slog.String(fPayload, "xxxx").Equal(got[0]))
+	assert.True(t, slog.Bool(fPayloadTruncated, true).Equal(got[1]))
+}
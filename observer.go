@@ -15,14 +15,81 @@
 //	    Fields:  []wrpslog.FieldOpt{wrpslog.Source(), wrpslog.Destination()},
 //	}
 //
+// New creates an Observer from options and validates the configuration:
+//
+//	ob, err := wrpslog.New(
+//	    wrpslog.WithLogger(slog.Default()),
+//	    wrpslog.WithMessage("wrp message"),
+//	    wrpslog.WithFields(wrpslog.Source(), wrpslog.Destination()),
+//	)
+//
+// NewObserver does the same from a Config, which can be loaded from JSON or
+// YAML.
+//
 // # Field Selection
 //
 // Fields are configured using FieldOpt functions. Each field option sets a
 // specific slot, so duplicate calls to the same field (e.g., MessageType and
-// MessageTypeAsString) will overwrite - the last one wins.
+// MessageTypeAsString) will overwrite - the last one wins. Observers created
+// with New report such conflicts as errors instead.
+//
+// Custom fields can be added with Field and FieldAlways. These are logged after
+// the built-in fields, in the order they are configured.
 //
 // Empty or zero-value fields are automatically omitted from log output.
 //
+// Fields are logged under the JSON tag names by default. KeyNames renames
+// them, e.g. to follow a logging schema such as wrp.destination.
+//
+// KeyPrefix is prepended to every key instead, for backends that index flat
+// keys such as wrp_source better than nested objects. Keys are renamed and
+// prefixed once, when the fields are compiled, not for every message.
+//
+// Setting Group logs all fields inside a single group, such as "wrp", so they
+// cannot collide with other attributes on the logger. JSON handlers render
+// the group as a nested object.
+//
+// # Levels
+//
+// Messages are logged at Level, or at the level returned by LevelFunc when it
+// is set. Level accepts any slog.Leveler, so a shared *slog.LevelVar can
+// change the level of running observers. LevelByType builds a LevelFunc from
+// a map of message types. An Escalation raises the level of messages carrying
+// an error status, such as Warn for 4xx and Error for 5xx responses.
+//
+// # Sampling
+//
+// Setting Sampler logs only some of the messages, such as one in a hundred
+// with OneInN. Messages that are not sampled are dropped before any field is
+// extracted. Sampled messages carry a sample_rate attribute giving the number
+// of messages each one stands for.
+//
+// ConsistentSampler samples whole transactions or devices instead of single
+// messages, so request/response pairs and device timelines stay complete.
+// Processes that share a seed make the same decisions.
+//
+// A Limiter puts a hard ceiling on the rate messages are logged at, for all
+// messages or per device or message type. Dropped messages are counted and
+// reported every interval by summary records such as
+// "wrp message suppressed" count=1234 key=mac:112233445566.
+//
+// A Deduper drops repeats of the same message, such as device retries,
+// within a window. The number dropped is logged as repeat_count on the next
+// copy of the message that is logged, or on a summary record once the window
+// has ended.
+//
+// Summaries are logged by ObserveWRP once they are due. Run logs them even
+// when no more messages arrive, and Flush logs them at shutdown.
+//
+// # Redaction
+//
+// Setting Redactor replaces the device identifiers (mac:, uuid: and serial:)
+// in the source, destination, device ID, event subpath, headers, metadata
+// and URL fields, and in payloads logged as text or decoded by
+// PayloadJSONFields and PayloadMsgpack, before they are logged. Payloads
+// logged base64 encoded are not redacted. HMACRedactor keeps the same device
+// correlatable across log lines without revealing its identifier.
+//
 // # Performance
 //
 // The observer is designed for minimal allocations.
@@ -31,7 +98,11 @@ package wrpslog
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/xmidt-org/wrp-go/v5"
 )
@@ -41,37 +112,133 @@ import (
 // The observer must be used as a pointer (&Observer{}) to ensure proper
 // initialization via sync.Once.
 //
-// Configuration fields (Logger, Level, Message, Fields) are read once on the
-// first call to ObserveWRP. Modifications to these fields after the first call
-// have no effect.
+// Fields, Redactor, KeyNames, KeyPrefix and Group are read once, on the
+// first call to ObserveWRP, Flush or Reconfigure. Later changes to them have
+// no effect, and Reconfigure keeps the values read then. Use a
+// *slog.LevelVar as the Level to change the level at runtime, and
+// Reconfigure to change the logged fields.
 type Observer struct {
 	// Logger is the slog.Logger to use. If nil, logging is skipped.
 	Logger *slog.Logger
 
-	// Level is the log level to use.
-	Level slog.Level
+	// Level is the log level to use. It is consulted for every message, so a
+	// shared *slog.LevelVar can change the level at runtime. If nil,
+	// slog.LevelInfo is used.
+	Level slog.Leveler
+
+	// LevelFunc, if set, returns the log level for each message and is used
+	// instead of Level. It is called before the logger is checked for the
+	// level, so it must be cheap. See LevelByType.
+	LevelFunc func(context.Context, wrp.Message) slog.Level
+
+	// Escalation, if set, raises the level of messages that carry an error
+	// Status or RequestDeliveryResponse. See DefaultEscalation.
+	Escalation *Escalation
 
 	// Message is the log message text.
 	Message string
 
 	// Fields specifies which WRP fields to include in log output.
 	// Each FieldOpt configures a specific field slot; duplicates overwrite.
+	// Custom fields are appended after the built-in slots.
 	Fields []FieldOpt
 
-	once   sync.Once
-	fields [fieldCount]fieldFunc
+	// Redactor, if set, replaces device identifiers in locator-bearing fields
+	// before they are logged.
+	Redactor Redactor
+
+	// KeyNames renames the keys of built-in fields. It maps the default key,
+	// such as "dest", to the key to log instead, such as "wrp.destination".
+	// Keys that are not listed keep their default name.
+	KeyNames map[string]string
+
+	// KeyPrefix, if set, is prepended to the key of every built-in and custom
+	// field, after any renaming by KeyNames.
+	KeyPrefix string
+
+	// Group, if set, is the key of a group that all fields are logged in.
+	Group string
+
+	// Sampler, if set, decides which messages are logged. It is consulted
+	// for every message. See FixedRate, OneInN and ConsistentSampler.
+	Sampler Sampler
+
+	// Limiter, if set, caps the rate messages are logged at and reports the
+	// messages it drops. See Run and Flush.
+	Limiter *Limiter
+
+	// Deduper, if set, drops repeats of a message within a window and
+	// reports how many were dropped. See Run and Flush.
+	Deduper *Deduper
+
+	once    sync.Once
+	base    plan // the settings read on first use, without fields
+	current atomic.Pointer[plan]
 }
 
 var _ wrp.Observer = &Observer{}
 
-func (ob *Observer) init() {
+// plan is the compiled set of fields logged by an Observer. A plan is never
+// modified once it is in use; Reconfigure swaps in a new one.
+type plan struct {
+	fields   [fieldCount]fieldFunc
+	custom   []fieldFunc
+	names    []string // the names of the custom fields, in order
+	redactor Redactor
+	keys     map[string]string
+	prefix   string
+	group    string
+
+	// sampleKey and repeatKey are the keys of the sample rate and repeat
+	// count, computed once with the other keys.
+	sampleKey string
+	repeatKey string
+}
+
+// key returns the key a built-in field named name is logged under.
+func (p *plan) key(name string) string {
+	if k := p.keys[name]; k != "" {
+		name = k
+	}
+	return p.prefix + name
+}
+
+// compile builds the plan for fields from the settings read on first use.
+func (ob *Observer) compile(fields []FieldOpt) *plan {
+	p := ob.base
+	for _, opt := range fields {
+		if opt != nil {
+			opt(&p)
+		}
+	}
+	p.sampleKey = p.key(fSampleRate)
+	p.repeatKey = p.key(fRepeatCount)
+	return &p
+}
+
+// init returns the current plan. On first use it reads the settings shared
+// by all plans and compiles the plan for Fields.
+func (ob *Observer) init() *plan {
 	ob.once.Do(func() {
-		for _, opt := range ob.Fields {
-			if opt != nil {
-				opt(ob)
-			}
+		ob.base = plan{
+			redactor: ob.Redactor,
+			keys:     maps.Clone(ob.KeyNames),
+			prefix:   ob.KeyPrefix,
+			group:    ob.Group,
 		}
+		ob.current.Store(ob.compile(ob.Fields))
 	})
+	return ob.current.Load()
+}
+
+// Reconfigure replaces the fields logged by the observer, for example to add
+// payload logging during an incident. It is safe to call concurrently with
+// ObserveWRP: calls already in progress finish with the previous fields and
+// later calls use the new ones. The Fields field is not modified, and the
+// Redactor, KeyNames, KeyPrefix and Group read on first use are kept.
+func (ob *Observer) Reconfigure(fields ...FieldOpt) {
+	ob.init()
+	ob.current.Store(ob.compile(fields))
 }
 
 // ObserveWRP logs information about the message being processed.
@@ -80,22 +247,209 @@ func (ob *Observer) ObserveWRP(ctx context.Context, msg wrp.Message) {
 		return
 	}
 
-	if !ob.Logger.Enabled(ctx, ob.Level) {
+	level := ob.level(ctx, msg)
+	if !ob.Logger.Enabled(ctx, level) {
 		return
 	}
 
-	ob.init()
+	rate, sampled := 0.0, false
+	if ob.Sampler != nil {
+		if rate, sampled = ob.Sampler(ctx, msg); !sampled {
+			return
+		}
+	}
+
+	p := ob.init()
+
+	var buf [2]slog.Attr
+	extra := buf[:0]
+	if sampled {
+		extra = append(extra, slog.Float64(p.sampleKey, rate))
+	}
+
+	var repeats int64
+	if ob.Deduper != nil {
+		var ok bool
+		var due []repeated
+		ok, repeats, due = ob.Deduper.check(msg)
+		ob.logRepeated(ctx, p, due)
+		if !ok {
+			return
+		}
+		if repeats > 0 {
+			extra = append(extra, slog.Int64(p.repeatKey, repeats))
+		}
+	}
+
+	if ob.Limiter != nil {
+		ok, due := ob.Limiter.allow(msg, p.redactor)
+		ob.logSuppressed(ctx, p, due)
+		if !ok {
+			// The repeats this copy would have carried are reported later.
+			if repeats > 0 {
+				ob.Deduper.restore(msg, repeats)
+			}
+			return
+		}
+	}
+
+	ob.logMessage(ctx, p, level, ob.Message, msg, extra)
+}
+
+// Run logs the summaries of the Limiter and the Deduper as they fall due,
+// until ctx is done, and then calls Flush. Without Run, summaries are only
+// logged by later calls to ObserveWRP, so the messages dropped in a burst
+// followed by silence are not reported until traffic resumes. Run blocks, so
+// it is usually started in its own goroutine:
+//
+//	go ob.Run(ctx)
+//
+// Run returns at once if Logger is not set or neither Limiter nor Deduper is.
+func (ob *Observer) Run(ctx context.Context) {
+	if ob.Logger == nil || (ob.Limiter == nil && ob.Deduper == nil) {
+		return
+	}
+
+	var period time.Duration
+	if ob.Limiter != nil {
+		ob.Limiter.init()
+		period = ob.Limiter.interval
+	}
+	if ob.Deduper != nil {
+		ob.Deduper.init()
+		if period == 0 || ob.Deduper.window < period {
+			period = ob.Deduper.window
+		}
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ob.Flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			if ob.Deduper != nil {
+				ob.logRepeated(ctx, ob.init(), ob.Deduper.due())
+			}
+			if ob.Limiter != nil {
+				ob.logSuppressed(ctx, ob.init(), ob.Limiter.due())
+			}
+		}
+	}
+}
+
+// Flush logs the summaries of the messages dropped by the Deduper and the
+// Limiter that have not been reported yet. Call it before the observer is
+// discarded, such as when the service shuts down.
+func (ob *Observer) Flush(ctx context.Context) {
+	if ob.Logger == nil {
+		return
+	}
+	if ob.Deduper != nil {
+		ob.logRepeated(ctx, ob.init(), ob.Deduper.flush())
+	}
+	if ob.Limiter != nil {
+		ob.logSuppressed(ctx, ob.init(), ob.Limiter.flush())
+	}
+}
 
-	var idx int
-	var attrs [fieldCount]slog.Attr
-	for _, fn := range ob.fields {
+// logMessage logs the fields of msg, followed by extra.
+func (ob *Observer) logMessage(ctx context.Context, p *plan, level slog.Level, message string, msg wrp.Message, extra []slog.Attr) {
+	// Built-in fields and the extra attributes fit in the fixed array; only
+	// custom fields that overflow it cause the slice to grow.
+	var buf [fieldCount + 2]slog.Attr
+	attrs := buf[:0]
+	for _, fn := range p.fields {
 		if fn != nil {
-			if attr := fn(msg); attr.Key != "" {
-				attrs[idx] = attr
-				idx++
+			if attr := fn(msg); !skip(attr) {
+				attrs = append(attrs, attr)
 			}
 		}
 	}
+	for _, fn := range p.custom {
+		if attr := fn(msg); !skip(attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+	attrs = append(attrs, extra...)
+
+	ob.log(ctx, p, level, message, attrs)
+}
+
+// log logs attrs, inside the group of p if it has one.
+func (ob *Observer) log(ctx context.Context, p *plan, level slog.Level, message string, attrs []slog.Attr) {
+	if p.group != "" {
+		// The group keeps its own copy so that the caller's attrs do not
+		// escape.
+		group := slog.Attr{Key: p.group, Value: slog.GroupValue(slices.Clone(attrs)...)}
+		ob.Logger.LogAttrs(ctx, level, message, group)
+		return
+	}
+
+	ob.Logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// logSuppressed logs a summary record for each entry of due. Summaries are
+// logged at Level, the level of healthy traffic.
+func (ob *Observer) logSuppressed(ctx context.Context, p *plan, due []suppressed) {
+	if len(due) == 0 {
+		return
+	}
+
+	level := ob.baseLevel()
+	if !ob.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	for _, s := range due {
+		attrs := []slog.Attr{slog.Int64(p.key(fCount), s.count)}
+		if s.label != "" {
+			attrs = append(attrs, slog.String(p.key(fKey), s.label))
+		}
+		ob.log(ctx, p, level, ob.Limiter.message, attrs)
+	}
+}
+
+// logRepeated logs a summary record for each entry of due, with the fields
+// of the repeated message and its repeat count.
+func (ob *Observer) logRepeated(ctx context.Context, p *plan, due []repeated) {
+	for _, r := range due {
+		level := ob.level(ctx, r.msg)
+		if !ob.Logger.Enabled(ctx, level) {
+			continue
+		}
+		extra := [...]slog.Attr{slog.Int64(p.repeatKey, r.count)}
+		ob.logMessage(ctx, p, level, ob.Deduper.message, r.msg, extra[:])
+	}
+}
+
+// baseLevel returns Level, or slog.LevelInfo if it is not set.
+func (ob *Observer) baseLevel() slog.Level {
+	if ob.Level != nil {
+		return ob.Level.Level()
+	}
+	return slog.LevelInfo
+}
+
+// level returns the level msg is logged at.
+func (ob *Observer) level(ctx context.Context, msg wrp.Message) slog.Level {
+	level := ob.baseLevel()
+	if ob.LevelFunc != nil {
+		level = ob.LevelFunc(ctx, msg)
+	}
+
+	if escalated, ok := ob.Escalation.level(msg); ok {
+		level = max(level, escalated)
+	}
+	return level
+}
 
-	ob.Logger.LogAttrs(ctx, ob.Level, ob.Message, attrs[:idx]...)
+// skip reports whether a field returned attr to indicate it should be omitted.
+// A group with an empty key is kept; handlers inline its attrs, which lets a
+// single field log more than one attribute.
+func skip(attr slog.Attr) bool {
+	return attr.Key == "" && attr.Value.Kind() != slog.KindGroup
 }
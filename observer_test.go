@@ -4,11 +4,15 @@
 package wrpslog
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -60,8 +64,8 @@ func TestObserver_FieldOptions(t *testing.T) {
 		Accept:                  "application/msgpack",
 		Status:                  &status,
 		RequestDeliveryResponse: &rdr,
-		Headers:                 []string{"X-Header: value"},
-		Metadata:                map[string]string{"key": "value"},
+		Headers:                 []string{"X-Header: value", "no-colon"},
+		Metadata:                map[string]string{"key": "value", "another": "one"},
 		Path:                    "/api/v1/test",
 		Payload:                 payload,
 		ServiceName:             "test-service",
@@ -122,8 +126,8 @@ func TestObserver_FieldOptions(t *testing.T) {
 		fAccept:                  "application/msgpack",
 		fStatus:                  int64(200),
 		fRequestDeliveryResponse: int64(1),
-		fHeaders:                 []string{"X-Header: value"},
-		fMetadata:                map[string]string{"key": "value"},
+		fHeaders:                 []slog.Attr{slog.String("X-Header", "value"), slog.String("1", "no-colon")},
+		fMetadata:                []slog.Attr{slog.String("another", "one"), slog.String("key", "value")},
 		fPath:                    "/api/v1/test",
 		fPayload:                 base64.StdEncoding.EncodeToString(payload),
 		fPayloadSize:             int64(len(payload)),
@@ -335,3 +339,430 @@ func TestEnsureNoMissingFields(t *testing.T) {
 		assert.True(t, found, "Constant for field '%s' (%s) does not match the JSON tag: %s", field.Name, tag, jsonTag)
 	}
 }
+
+func TestObserver_CustomFields(t *testing.T) {
+	partnerCount := func(msg wrp.Message) slog.Attr {
+		return slog.Int("", len(msg.PartnerIDs))
+	}
+	firstPartner := func(msg wrp.Message) slog.Attr {
+		if len(msg.PartnerIDs) == 0 {
+			return slog.Attr{}
+		}
+		return slog.String("ignored", msg.PartnerIDs[0])
+	}
+	var zero int64
+
+	tests := []struct {
+		name     string
+		fields   []FieldOpt
+		msg      wrp.Message
+		expected []slog.Attr
+	}{
+		{
+			name:   "after_builtins_in_order",
+			fields: []FieldOpt{Field("first_partner", firstPartner), Source(), Field("partner_count", partnerCount)},
+			msg:    wrp.Message{Source: "dns:example.com", PartnerIDs: []string{"comcast", "sky"}},
+			expected: []slog.Attr{
+				slog.String(fSource, "dns:example.com"),
+				slog.String("first_partner", "comcast"),
+				slog.Int("partner_count", 2),
+			},
+		},
+		{
+			name:     "empty_omitted",
+			fields:   []FieldOpt{Field("first_partner", firstPartner)},
+			msg:      wrp.Message{},
+			expected: nil,
+		},
+		{
+			name:     "empty_always",
+			fields:   []FieldOpt{FieldAlways("first_partner", firstPartner)},
+			msg:      wrp.Message{},
+			expected: []slog.Attr{{Key: "first_partner"}},
+		},
+		{
+			name: "nil_values_omitted",
+			fields: []FieldOpt{
+				Field("slice", func(wrp.Message) slog.Attr { return slog.Any("", []string(nil)) }),
+				Field("map", func(wrp.Message) slog.Attr { return slog.Any("", map[string]string(nil)) }),
+				Field("pointer", func(wrp.Message) slog.Attr { return slog.Any("", (*int64)(nil)) }),
+				Field("set_pointer", func(wrp.Message) slog.Attr { return slog.Any("", &zero) }),
+			},
+			msg:      wrp.Message{},
+			expected: []slog.Attr{slog.Any("set_pointer", &zero)},
+		},
+		{
+			name:     "invalid_ignored",
+			fields:   []FieldOpt{Field("", firstPartner), FieldAlways("nil_fn", nil)},
+			msg:      wrp.Message{PartnerIDs: []string{"comcast"}},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRecordHandler(slog.LevelInfo)
+			ob := Observer{
+				Logger:  slog.New(handler),
+				Level:   slog.LevelInfo,
+				Message: "wrp message",
+				Fields:  tt.fields,
+			}
+
+			ob.ObserveWRP(context.Background(), tt.msg)
+
+			require.Len(t, handler.records, 1)
+			attrs := handler.getAttrs(0)
+			require.Len(t, attrs, len(tt.expected))
+			for i, attr := range attrs {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestObserver_MetadataSelection(t *testing.T) {
+	msg := wrp.Message{
+		Metadata: map[string]string{
+			"hw-model":    "XB7",
+			"fw-name":     "fw-1.2.3",
+			"/boot-time":  "1700000000",
+			"partner-key": "secret",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		field    FieldOpt
+		msg      wrp.Message
+		expected []slog.Attr
+	}{
+		{
+			name:  "keys",
+			field: MetadataKeys("hw-model", "missing", "fw-name", "hw-model"),
+			msg:   msg,
+			expected: []slog.Attr{
+				slog.Group(fMetadata,
+					slog.String("fw-name", "fw-1.2.3"),
+					slog.String("hw-model", "XB7"),
+				),
+			},
+		},
+		{
+			name:  "except",
+			field: MetadataExcept("partner-key"),
+			msg:   msg,
+			expected: []slog.Attr{
+				slog.Group(fMetadata,
+					slog.String("/boot-time", "1700000000"),
+					slog.String("fw-name", "fw-1.2.3"),
+					slog.String("hw-model", "XB7"),
+				),
+			},
+		},
+		{
+			name:  "keys_none_present",
+			field: MetadataKeys("missing"),
+			msg:   msg,
+		},
+		{
+			name:  "except_all",
+			field: MetadataExcept("hw-model", "fw-name", "/boot-time", "partner-key"),
+			msg:   msg,
+		},
+		{
+			name:  "keys_empty_metadata",
+			field: MetadataKeys("hw-model"),
+			msg:   wrp.Message{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRecordHandler(slog.LevelInfo)
+			ob := Observer{
+				Logger:  slog.New(handler),
+				Level:   slog.LevelInfo,
+				Message: "wrp message",
+				Fields:  []FieldOpt{tt.field},
+			}
+
+			ob.ObserveWRP(context.Background(), tt.msg)
+
+			require.Len(t, handler.records, 1)
+			attrs := handler.getAttrs(0)
+			require.Len(t, attrs, len(tt.expected))
+			for i, attr := range attrs {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestObserver_Headers(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  []string
+		expected []slog.Attr
+	}{
+		{
+			name:    "named",
+			headers: []string{"X-Midt-Version: 1", "X-Midt-Trace:abc123"},
+			expected: []slog.Attr{
+				slog.String("X-Midt-Version", "1"),
+				slog.String("X-Midt-Trace", "abc123"),
+			},
+		}, {
+			name:    "repeated_name",
+			headers: []string{"X-Retry: 1", "X-Midt-Trace: abc123", "X-Retry: 2"},
+			expected: []slog.Attr{
+				slog.String("0", "X-Retry: 1"),
+				slog.String("1", "X-Midt-Trace: abc123"),
+				slog.String("2", "X-Retry: 2"),
+			},
+		}, {
+			name:    "name_like_index",
+			headers: []string{"no-colon", "0: zero"},
+			expected: []slog.Attr{
+				slog.String("0", "no-colon"),
+				slog.String("1", "0: zero"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := observeOne(t, wrp.Message{Headers: tt.headers}, Headers())
+			require.Len(t, attrs, 1)
+			assert.Equal(t, fHeaders, attrs[0].Key)
+			assert.Equal(t, tt.expected, attrs[0].Value.Group())
+		})
+	}
+
+	t.Run("many", func(t *testing.T) {
+		headers := make([]string, 20)
+		for i := range headers {
+			headers[i] = fmt.Sprintf("X-Header-%d: %d", i%19, i)
+		}
+
+		attrs := observeOne(t, wrp.Message{Headers: headers}, Headers())
+		require.Len(t, attrs, 1)
+		group := attrs[0].Value.Group()
+		require.Len(t, group, 20)
+		assert.Equal(t, slog.String("19", "X-Header-0: 19"), group[19])
+	})
+}
+
+func TestObserver_KeyNames(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields: []FieldOpt{
+			MessageTypeAsString(),
+			Destination(),
+			EventType(),
+			PayloadPreview(2),
+		},
+		KeyNames: map[string]string{
+			fMsgType:          "wrp.type",
+			fDestination:      "wrp.destination",
+			fEventType:        "event.type",
+			fPayloadTruncated: "wrp.payload_truncated",
+		},
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Destination: "event:device-status/mac:112233445566/online",
+		ContentType: "text/plain",
+		Payload:     []byte("hello"),
+	})
+
+	require.Len(t, handler.records, 1)
+	attrs := handler.getAttrs(0)
+	keys := make(map[string]slog.Value, len(attrs))
+	for _, attr := range attrs {
+		keys[attr.Key] = attr.Value
+	}
+	assert.Len(t, keys, 5)
+	assert.Contains(t, keys, "wrp.type")
+	assert.Contains(t, keys, "wrp.destination")
+	assert.Equal(t, "device-status", keys["event.type"].String())
+	assert.Contains(t, keys, fPayload, "keys not renamed keep their default")
+	assert.Contains(t, keys, "wrp.payload_truncated")
+}
+
+func TestObserver_KeyPrefix(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields: []FieldOpt{
+			Source(),
+			Destination(),
+			PayloadPreview(2),
+			Field("partner", func(msg wrp.Message) slog.Attr {
+				return slog.String("", msg.PartnerIDs[0])
+			}),
+		},
+		KeyNames:  map[string]string{fDestination: "destination"},
+		KeyPrefix: "wrp_",
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Source:      "dns:example.com",
+		Destination: "mac:112233445566",
+		ContentType: "text/plain",
+		Payload:     []byte("hello"),
+		PartnerIDs:  []string{"comcast"},
+	})
+
+	require.Len(t, handler.records, 1)
+	var keys []string
+	for _, attr := range handler.getAttrs(0) {
+		keys = append(keys, attr.Key)
+	}
+	assert.Equal(t, []string{"wrp_source", "wrp_destination", "wrp_payload", "wrp_payload_truncated", "wrp_partner"}, keys)
+}
+
+func TestObserver_Group(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source(), Status(), PayloadPreview(2)},
+		Group:   "wrp",
+	}
+
+	status := int64(200)
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Source:      "dns:example.com",
+		Status:      &status,
+		ContentType: "text/plain",
+		Payload:     []byte("hello"),
+	})
+
+	require.Len(t, handler.records, 1)
+	var attrs []slog.Attr
+	handler.records[0].Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	require.Len(t, attrs, 1)
+	assert.Equal(t, "wrp", attrs[0].Key)
+	require.Equal(t, slog.KindGroup, attrs[0].Value.Kind())
+
+	group := attrs[0].Value.Group()
+	require.Len(t, group, 3)
+	assert.True(t, slog.String(fSource, "dns:example.com").Equal(group[0]))
+	assert.True(t, slog.Int64(fStatus, 200).Equal(group[1]))
+	assert.Equal(t, slog.KindGroup, group[2].Value.Kind(), "inline groups are kept")
+}
+
+func TestObserver_GroupJSON(t *testing.T) {
+	var buf bytes.Buffer
+	ob := Observer{
+		Logger:  slog.New(slog.NewJSONHandler(&buf, nil)),
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source(), PayloadSize()},
+		Group:   "wrp",
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{Source: "dns:example.com", Payload: []byte("hello")})
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, map[string]any{"source": "dns:example.com", "payload_size": 5.0}, record["wrp"])
+}
+
+func TestObserver_Reconfigure(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source()},
+	}
+
+	msg := wrp.Message{Source: "dns:example.com", Payload: []byte("hello")}
+
+	ob.ObserveWRP(context.Background(), msg)
+	ob.Reconfigure(Source(), PayloadSize())
+	ob.ObserveWRP(context.Background(), msg)
+
+	require.Len(t, handler.records, 2)
+	assert.Len(t, handler.getAttrs(0), 1)
+	assert.Len(t, handler.getAttrs(1), 2)
+	assert.Len(t, ob.Fields, 1, "Fields is not modified")
+}
+
+func TestObserver_ReconfigureBeforeFirstUse(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:  slog.New(handler),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source()},
+	}
+
+	ob.Reconfigure(PayloadSize())
+	ob.ObserveWRP(context.Background(), wrp.Message{Source: "dns:example.com", Payload: []byte("hello")})
+
+	require.Len(t, handler.records, 1)
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 1)
+	assert.Equal(t, fPayloadSize, attrs[0].Key)
+}
+
+func TestObserver_ReconfigureKeepsSettings(t *testing.T) {
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:    slog.New(handler),
+		Level:     slog.LevelInfo,
+		Message:   "wrp message",
+		Fields:    []FieldOpt{Source()},
+		KeyPrefix: "wrp_",
+		Redactor:  MaskRedactor(),
+	}
+
+	msg := wrp.Message{Source: "mac:112233445566"}
+	ob.ObserveWRP(context.Background(), msg)
+
+	ob.KeyPrefix = ""
+	ob.Redactor = nil
+	ob.Reconfigure(Source())
+	ob.ObserveWRP(context.Background(), msg)
+
+	require.Len(t, handler.records, 2)
+	for i := range handler.records {
+		assert.Equal(t, []slog.Attr{slog.String("wrp_source", "mac:************")}, handler.getAttrs(i))
+	}
+}
+
+func TestObserver_ReconfigureConcurrent(t *testing.T) {
+	ob := Observer{
+		Logger:  slog.New(discardHandler{}),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields:  []FieldOpt{Source()},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ob.ObserveWRP(context.Background(), wrp.Message{Source: "dns:example.com"})
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		ob.Reconfigure(Source(), Destination(), PayloadSize())
+	}
+	wg.Wait()
+}
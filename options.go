@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// ErrFieldConflict is returned by New when two field options set the same
+// field slot, such as MessageType and MessageTypeAsString, when two custom
+// fields use the same key, or when a custom field uses the key of a built-in
+// field.
+var ErrFieldConflict = errors.New("conflicting field options")
+
+// Option configures an Observer created by New.
+type Option func(*Observer) error
+
+// WithLogger sets the Logger. It is required.
+func WithLogger(logger *slog.Logger) Option {
+	return func(ob *Observer) error {
+		ob.Logger = logger
+		return nil
+	}
+}
+
+// WithLevel sets the Level.
+func WithLevel(level slog.Leveler) Option {
+	return func(ob *Observer) error {
+		ob.Level = level
+		return nil
+	}
+}
+
+// WithLevelFunc sets the LevelFunc.
+func WithLevelFunc(fn func(context.Context, wrp.Message) slog.Level) Option {
+	return func(ob *Observer) error {
+		ob.LevelFunc = fn
+		return nil
+	}
+}
+
+// WithEscalation sets the Escalation.
+func WithEscalation(e *Escalation) Option {
+	return func(ob *Observer) error {
+		ob.Escalation = e
+		return nil
+	}
+}
+
+// WithMessage sets the Message. It is required.
+func WithMessage(msg string) Option {
+	return func(ob *Observer) error {
+		ob.Message = msg
+		return nil
+	}
+}
+
+// WithFields appends to the Fields.
+func WithFields(fields ...FieldOpt) Option {
+	return func(ob *Observer) error {
+		ob.Fields = append(ob.Fields, fields...)
+		return nil
+	}
+}
+
+// WithRedactor sets the Redactor.
+func WithRedactor(r Redactor) Option {
+	return func(ob *Observer) error {
+		ob.Redactor = r
+		return nil
+	}
+}
+
+// WithKeyNames sets the KeyNames. Every key renamed must be the default key
+// of a built-in field.
+func WithKeyNames(names map[string]string) Option {
+	return func(ob *Observer) error {
+		ob.KeyNames = names
+		return nil
+	}
+}
+
+// WithKeyPrefix sets the KeyPrefix prepended to every field key.
+func WithKeyPrefix(prefix string) Option {
+	return func(ob *Observer) error {
+		ob.KeyPrefix = prefix
+		return nil
+	}
+}
+
+// WithGroup sets the Group that all fields are logged in.
+func WithGroup(group string) Option {
+	return func(ob *Observer) error {
+		ob.Group = group
+		return nil
+	}
+}
+
+// WithSampler sets the Sampler that decides which messages are logged.
+func WithSampler(sampler Sampler) Option {
+	return func(ob *Observer) error {
+		ob.Sampler = sampler
+		return nil
+	}
+}
+
+// WithLimiter sets the Limiter that caps the rate messages are logged at.
+func WithLimiter(limiter *Limiter) Option {
+	return func(ob *Observer) error {
+		ob.Limiter = limiter
+		return nil
+	}
+}
+
+// WithDeduper sets the Deduper that drops repeats of a message.
+func WithDeduper(deduper *Deduper) Option {
+	return func(ob *Observer) error {
+		ob.Deduper = deduper
+		return nil
+	}
+}
+
+// New creates an Observer from opts and validates it. Unlike a bare Observer,
+// which silently skips logging without a Logger and lets the last of two
+// options for the same field win, New reports these mistakes as errors:
+//
+//   - a missing Logger, empty Message or KeyNames entry that does not name a
+//     built-in field wraps ErrInvalidConfig
+//   - two field options that set the same slot wrap ErrFieldConflict
+//
+// All problems found are returned together. The returned Observer has its
+// fields compiled and is ready to use.
+func New(opts ...Option) (*Observer, error) {
+	var ob Observer
+	var errs []error
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&ob); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if ob.Logger == nil {
+		errs = append(errs, fmt.Errorf("%w: a logger is required", ErrInvalidConfig))
+	}
+	if ob.Message == "" {
+		errs = append(errs, fmt.Errorf("%w: a message is required", ErrInvalidConfig))
+	}
+	errs = append(errs, checkKeyNames(ob.KeyNames)...)
+	errs = append(errs, checkFields(ob.Fields, ob.KeyNames)...)
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	ob.init()
+	return &ob, nil
+}
+
+// checkFields returns an error for every field option that sets a slot
+// already set by an earlier option, every custom field whose key is already
+// used by an earlier custom field, and every custom field whose key is the
+// key of a built-in field once renamed by names.
+func checkFields(fields []FieldOpt, names map[string]string) []error {
+	builtin := make(map[string]bool)
+	for name := range builtinKeys() {
+		builtin[cmp.Or(names[name], name)] = true
+	}
+
+	var errs []error
+	var owners [fieldCount]int       // 1 + the index of the option setting the slot
+	customOwners := map[string]int{} // the index of the option setting the custom key
+	for i, opt := range fields {
+		if opt == nil {
+			continue
+		}
+
+		var p plan
+		opt(&p)
+		for slot, fn := range p.fields {
+			if fn == nil {
+				continue
+			}
+			if prev := owners[slot]; prev != 0 {
+				errs = append(errs, fmt.Errorf("%w: fields %d and %d both set %s",
+					ErrFieldConflict, prev-1, i, fieldIndex(slot)))
+				continue
+			}
+			owners[slot] = i + 1
+		}
+
+		for _, name := range p.names {
+			if builtin[name] {
+				errs = append(errs, fmt.Errorf("%w: field %d uses the key %s of a built-in field",
+					ErrFieldConflict, i, name))
+				continue
+			}
+			if prev, found := customOwners[name]; found {
+				errs = append(errs, fmt.Errorf("%w: fields %d and %d both set %s",
+					ErrFieldConflict, prev, i, name))
+				continue
+			}
+			customOwners[name] = i
+		}
+	}
+	return errs
+}
+
+// checkKeyNames returns an error for every renamed key that is not the key of
+// a built-in field, that is renamed to an empty key, or that is renamed to
+// the key of another built-in field.
+func checkKeyNames(names map[string]string) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if !isBuiltinKey(name) {
+			errs = append(errs, fmt.Errorf("%w: %q is not a built-in field key", ErrInvalidConfig, name))
+		} else if names[name] == "" {
+			errs = append(errs, fmt.Errorf("%w: %q is renamed to an empty key", ErrInvalidConfig, name))
+		}
+	}
+
+	owners := make(map[string]string) // the built-in field logged under a key
+	for name := range builtinKeys() {
+		key := cmp.Or(names[name], name)
+		if prev, found := owners[key]; found {
+			errs = append(errs, fmt.Errorf("%w: %q and %q are both logged as %q", ErrInvalidConfig, prev, name, key))
+			continue
+		}
+		owners[key] = name
+	}
+	return errs
+}
+
+// isBuiltinKey reports whether name is the default key of a built-in field.
+func isBuiltinKey(name string) bool {
+	for key := range builtinKeys() {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinKeys returns the default keys of the built-in fields, including the
+// attributes added to them such as payload_truncated and sample_rate.
+func builtinKeys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, name := range slotNames {
+			if !yield(name) {
+				return
+			}
+		}
+		for _, name := range [...]string{fPayloadTruncated, fPayloadError, fSampleRate, fCount, fKey, fRepeatCount} {
+			if !yield(name) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestNew(t *testing.T) {
+	handler := newRecordHandler(slog.LevelDebug)
+	status := int64(404)
+
+	ob, err := New(
+		WithLogger(slog.New(handler)),
+		WithLevel(slog.LevelDebug),
+		WithLevelFunc(LevelByType(nil, slog.LevelDebug)),
+		WithEscalation(DefaultEscalation()),
+		WithMessage("wrp traffic"),
+		WithFields(MessageTypeAsString(), Source()),
+		WithFields(DeviceID()),
+		WithRedactor(MaskRedactor()),
+		nil,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, ob)
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Type:   wrp.SimpleEventMessageType,
+		Source: "mac:112233445566",
+		Status: &status,
+	})
+
+	require.Len(t, handler.records, 1)
+	assert.Equal(t, "wrp traffic", handler.records[0].Message)
+	assert.Equal(t, slog.LevelWarn, handler.records[0].Level)
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 3)
+	assert.Equal(t, "mac:************", attrs[1].Value.String())
+}
+
+func TestNew_Errors(t *testing.T) {
+	errOption := errors.New("option failed")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []error
+	}{
+		{
+			name:     "missing_logger",
+			opts:     []Option{WithMessage("wrp message")},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name:     "missing_message",
+			opts:     []Option{WithLogger(slog.Default())},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name: "duplicate_slot",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithFields(MessageType(), Source(), MessageTypeAsString()),
+			},
+			expected: []error{ErrFieldConflict},
+		},
+		{
+			name: "same_slot_variants",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithFields(Metadata(), MetadataKeys("hw-model")),
+			},
+			expected: []error{ErrFieldConflict},
+		},
+		{
+			name: "unknown_key_name",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithKeyNames(map[string]string{"destination": "wrp.destination"}),
+			},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name: "empty_key_name",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithKeyNames(map[string]string{fDestination: ""}),
+			},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name: "colliding_key_name",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithFields(Source(), Destination()),
+				WithKeyNames(map[string]string{fDestination: fSource}),
+			},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name: "key_name_of_extra_attr",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				WithKeyNames(map[string]string{fSource: fSampleRate}),
+			},
+			expected: []error{ErrInvalidConfig},
+		},
+		{
+			name: "option_error",
+			opts: []Option{
+				WithLogger(slog.Default()),
+				WithMessage("wrp message"),
+				func(*Observer) error { return errOption },
+			},
+			expected: []error{errOption},
+		},
+		{
+			name:     "all_reported",
+			opts:     []Option{WithFields(Source(), SourceAlways())},
+			expected: []error{ErrInvalidConfig, ErrFieldConflict},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob, err := New(tt.opts...)
+			assert.Nil(t, ob)
+			for _, expected := range tt.expected {
+				assert.ErrorIs(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestNew_CustomFieldConflicts(t *testing.T) {
+	custom := func(wrp.Message) slog.Attr { return slog.String("", "x") }
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "duplicate_keys",
+			opts:     []Option{WithFields(Field("a", custom), Source(), Field("a", custom))},
+			expected: "fields 0 and 2 both set a",
+		}, {
+			name:     "builtin_key",
+			opts:     []Option{WithFields(Field("source", custom))},
+			expected: "field 0 uses the key source of a built-in field",
+		}, {
+			name:     "renamed_builtin_key",
+			opts:     []Option{WithKeyNames(map[string]string{fSource: "src"}), WithFields(Field("src", custom))},
+			expected: "field 0 uses the key src of a built-in field",
+		}, {
+			name:     "extra_builtin_key",
+			opts:     []Option{WithFields(Field(fSampleRate, custom))},
+			expected: "field 0 uses the key sample_rate of a built-in field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithLogger(slog.Default()), WithMessage("wrp message")}, tt.opts...)
+			_, err := New(opts...)
+			assert.ErrorIs(t, err, ErrFieldConflict)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	t.Run("distinct_keys", func(t *testing.T) {
+		_, err := New(
+			WithLogger(slog.Default()),
+			WithMessage("wrp message"),
+			WithKeyNames(map[string]string{fSource: "src"}),
+			WithFields(Field("a", custom), Field("b", custom), Field("source", custom), Source()),
+		)
+		assert.NoError(t, err, "the default key of a renamed field is free")
+	})
+}
+
+func TestNew_SwappedKeyNames(t *testing.T) {
+	_, err := New(
+		WithLogger(slog.Default()),
+		WithMessage("wrp message"),
+		WithFields(Source(), Destination()),
+		WithKeyNames(map[string]string{fSource: fDestination, fDestination: fSource}),
+	)
+	assert.NoError(t, err, "keys may be swapped as long as each is logged once")
+}
+
+func TestNew_ConflictMessage(t *testing.T) {
+	_, err := New(
+		WithLogger(slog.Default()),
+		WithMessage("wrp message"),
+		WithFields(MessageType(), MessageTypeAsString()),
+	)
+	assert.ErrorContains(t, err, "fields 0 and 1 both set msg_type")
+}
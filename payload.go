@@ -0,0 +1,307 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+// Names of the fields derived from the payload.
+const (
+	fPayloadTruncated = "payload_truncated"
+	fPayloadError     = "payload_error"
+	fPayloadDigest    = "payload_digest"
+)
+
+// DigestAlgorithm selects the hash used by PayloadDigest.
+type DigestAlgorithm int
+
+const (
+	// DigestSHA256 hashes the payload with SHA-256.
+	DigestSHA256 DigestAlgorithm = iota
+
+	// DigestFNV1a hashes the payload with 64-bit FNV-1a. It is much faster
+	// than SHA-256 but is not collision resistant.
+	DigestFNV1a
+)
+
+// maxJSONBytes is the size of the largest payload PayloadJSONFields decodes.
+const maxJSONBytes = 64 << 10
+
+// jsonPath is a path selected by PayloadJSONFields.
+type jsonPath struct {
+	key   string   // the path as configured, logged as the key of its value
+	steps []string // the object keys and array indexes of the path
+}
+
+// defaultPreviewBytes is the number of bytes PayloadPreview logs when maxBytes
+// is not set.
+const defaultPreviewBytes = 1024
+
+// PayloadPreview logs at most maxBytes of the payload. JSON and text/*
+// payloads are logged as text; anything else, including text that is not
+// valid UTF-8, is logged base64 encoded. When the payload is longer than
+// maxBytes, payload_truncated=true is logged as well. A maxBytes of zero or
+// less uses a default of 1024. Device identifiers in payloads logged as text
+// are redacted by the Redactor. Uses the same slot as PayloadAsBase64.
+//
+// Empty values are omitted.
+func PayloadPreview(maxBytes int) FieldOpt {
+	if maxBytes <= 0 {
+		maxBytes = defaultPreviewBytes
+	}
+	return func(p *plan) {
+		key, truncatedKey := p.key(fPayload), p.key(fPayloadTruncated)
+		redact := p.redactor
+		p.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Payload) == 0 {
+				return slog.Attr{}
+			}
+
+			payload, truncated := msg.Payload, false
+			if len(payload) > maxBytes {
+				payload, truncated = payload[:maxBytes], true
+			}
+
+			preview := previewPayload(msg.ContentType, payload, redact)
+			if !truncated {
+				return slog.String(key, preview)
+			}
+			return slog.Attr{Value: slog.GroupValue(
+				slog.String(key, preview),
+				slog.Bool(truncatedKey, true),
+			)}
+		}
+	}
+}
+
+// PayloadJSONFields logs values selected from a JSON payload as a group, e.g.
+// payload.status. Each path is a dot separated list of object keys or array
+// indexes, such as status or device.interfaces.0.name. Paths that are not
+// present are skipped and the group is omitted when none are found. Uses the
+// same slot as PayloadAsBase64.
+//
+// Only payloads with a JSON content type and of at most 64 KiB are decoded.
+// A payload that is larger, fails to decode or has data after its JSON value
+// is logged as a payload_error attribute instead. Device identifiers
+// in the strings logged are redacted by the Redactor. Like every field,
+// nothing is decoded unless the message is going to be logged.
+func PayloadJSONFields(paths ...string) FieldOpt {
+	split := make([]jsonPath, 0, len(paths))
+	for _, p := range paths {
+		if p != "" {
+			split = append(split, jsonPath{key: p, steps: strings.Split(p, ".")})
+		}
+	}
+
+	return func(p *plan) {
+		key, errorKey := p.key(fPayload), p.key(fPayloadError)
+		redact := p.redactor
+		p.fields[idxPayload] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Payload) == 0 || len(split) == 0 || !isJSONContentType(msg.ContentType) {
+				return slog.Attr{}
+			}
+
+			doc, err := decodeJSON(msg.Payload)
+			if err != nil {
+				return slog.String(errorKey, err.Error())
+			}
+
+			attrs := make([]slog.Attr, 0, len(split))
+			for _, path := range split {
+				if v, found := lookupJSON(doc, path.steps); found {
+					attrs = append(attrs, slog.Attr{Key: path.key, Value: jsonValue(v, redact)})
+				}
+			}
+			if len(attrs) == 0 {
+				return slog.Attr{}
+			}
+			return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+		}
+	}
+}
+
+// decodeJSON decodes a JSON payload of at most maxJSONBytes that holds a
+// single value, keeping numbers as json.Number.
+func decodeJSON(payload []byte) (any, error) {
+	if len(payload) > maxJSONBytes {
+		return nil, fmt.Errorf("payload of %d bytes is larger than %d bytes", len(payload), maxJSONBytes)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	switch _, err := dec.Token(); {
+	case err == io.EOF:
+		return doc, nil
+	case err != nil:
+		return nil, err
+	default:
+		return nil, errors.New("unexpected data after top-level value")
+	}
+}
+
+// lookupJSON returns the value found by following path from doc.
+func lookupJSON(doc any, path []string) (any, bool) {
+	for _, key := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, found := node[key]
+			if !found {
+				return nil, false
+			}
+			doc = v
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// jsonValue converts a decoded JSON value to an slog.Value, redacting the
+// device identifiers in its strings.
+func jsonValue(v any, redact Redactor) slog.Value {
+	switch v := v.(type) {
+	case string:
+		return slog.StringValue(redactDeviceIDs(v, redact))
+	case bool:
+		return slog.BoolValue(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return slog.Float64Value(f)
+		}
+		return slog.StringValue(v.String())
+	default:
+		return slog.AnyValue(redactJSON(v, redact))
+	}
+}
+
+// redactJSON returns a decoded JSON object or array with the device
+// identifiers in its keys and strings redacted. Arrays are redacted in place.
+func redactJSON(v any, redact Redactor) any {
+	if redact == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case string:
+		return redactDeviceIDs(v, redact)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, e := range v {
+			redacted[redactDeviceIDs(k, redact)] = redactJSON(e, redact)
+		}
+		return redacted
+	case []any:
+		for i, e := range v {
+			v[i] = redactJSON(e, redact)
+		}
+	}
+	return v
+}
+
+// PayloadDigest logs the hex encoded digest of the payload using alg, so the
+// same payload can be recognized across services without logging its bytes.
+// Unknown algorithms are ignored. Empty values are omitted.
+func PayloadDigest(alg DigestAlgorithm) FieldOpt {
+	return func(p *plan) {
+		key := p.key(fPayloadDigest)
+		if alg != DigestSHA256 && alg != DigestFNV1a {
+			return
+		}
+
+		p.fields[idxPayloadDigest] = func(msg wrp.Message) slog.Attr {
+			if len(msg.Payload) == 0 {
+				return slog.Attr{}
+			}
+			return slog.String(key, payloadDigest(alg, msg.Payload))
+		}
+	}
+}
+
+// payloadDigest returns the hex encoded digest of payload.
+func payloadDigest(alg DigestAlgorithm, payload []byte) string {
+	if alg == DigestFNV1a {
+		h := fnv.New64a()
+		h.Write(payload)
+
+		var sum [8]byte
+		binary.BigEndian.PutUint64(sum[:], h.Sum64())
+		return hex.EncodeToString(sum[:])
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// previewPayload returns payload as text with device identifiers redacted
+// when the content type allows it, or base64 encoded otherwise.
+func previewPayload(contentType string, payload []byte, redact Redactor) string {
+	if isTextContentType(contentType) {
+		if text := trimPartialRune(payload); utf8.Valid(text) {
+			return redactDeviceIDs(string(text), redact)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(payload)
+}
+
+// mediaType returns the lowercased media type of contentType without any
+// parameters.
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// isJSONContentType reports whether contentType describes a JSON payload.
+func isJSONContentType(contentType string) bool {
+	mt := mediaType(contentType)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// isTextContentType reports whether contentType describes a payload that can
+// be logged as text.
+func isTextContentType(contentType string) bool {
+	return isJSONContentType(contentType) || strings.HasPrefix(mediaType(contentType), "text/")
+}
+
+// trimPartialRune removes an incomplete UTF-8 sequence left at the end of b
+// by truncation.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if r := b[len(b)-i]; utf8.RuneStart(r) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
@@ -0,0 +1,319 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestPayloadPreview(t *testing.T) {
+	binary := []byte{0x82, 0xa1, 0x61, 0x01, 0xa1, 0x62, 0x02}
+
+	tests := []struct {
+		name        string
+		maxBytes    int
+		contentType string
+		payload     []byte
+		expected    []slog.Attr
+	}{
+		{
+			name:        "json",
+			maxBytes:    64,
+			contentType: "application/json; charset=utf-8",
+			payload:     []byte(`{"status":"online"}`),
+			expected:    []slog.Attr{slog.String(fPayload, `{"status":"online"}`)},
+		},
+		{
+			name:        "json_truncated",
+			maxBytes:    10,
+			contentType: "application/json",
+			payload:     []byte(`{"status":"online"}`),
+			expected: []slog.Attr{
+				slog.String(fPayload, `{"status":`),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "text_truncated_on_rune",
+			maxBytes:    4,
+			contentType: "text/plain",
+			payload:     []byte("abcé"),
+			expected: []slog.Attr{
+				slog.String(fPayload, "abc"),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "binary",
+			maxBytes:    64,
+			contentType: "application/msgpack",
+			payload:     binary,
+			expected:    []slog.Attr{slog.String(fPayload, base64.StdEncoding.EncodeToString(binary))},
+		},
+		{
+			name:        "binary_truncated",
+			maxBytes:    3,
+			contentType: "application/octet-stream",
+			payload:     binary,
+			expected: []slog.Attr{
+				slog.String(fPayload, base64.StdEncoding.EncodeToString(binary[:3])),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "invalid_text",
+			maxBytes:    64,
+			contentType: "text/plain",
+			payload:     binary,
+			expected:    []slog.Attr{slog.String(fPayload, base64.StdEncoding.EncodeToString(binary))},
+		},
+		{
+			name:        "default_cap",
+			contentType: "text/plain",
+			payload:     []byte(strings.Repeat("a", defaultPreviewBytes+1)),
+			expected: []slog.Attr{
+				slog.String(fPayload, strings.Repeat("a", defaultPreviewBytes)),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:        "negative_cap",
+			maxBytes:    -1,
+			contentType: "text/plain",
+			payload:     []byte("hello"),
+			expected:    []slog.Attr{slog.String(fPayload, "hello")},
+		},
+		{
+			name:     "empty",
+			maxBytes: 64,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := wrp.Message{ContentType: tt.contentType, Payload: tt.payload}
+
+			got := observeOne(t, msg, PayloadPreview(tt.maxBytes))
+			require.Len(t, got, len(tt.expected))
+			for i, attr := range got {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestPayloadJSONFields(t *testing.T) {
+	payload := []byte(`{"status":"online","boot-time":1700000000,"uptime":1.5,"device":{"ready":true,"interfaces":[{"name":"erouter0"}]}}`)
+
+	tests := []struct {
+		name        string
+		paths       []string
+		contentType string
+		payload     []byte
+		expected    []slog.Attr
+	}{
+		{
+			name:        "selected",
+			paths:       []string{"status", "boot-time", "uptime", "device.ready", "device.interfaces.0.name", "missing", "device.interfaces.5"},
+			contentType: "application/json",
+			payload:     payload,
+			expected: []slog.Attr{
+				slog.Group(fPayload,
+					slog.String("status", "online"),
+					slog.Int64("boot-time", 1700000000),
+					slog.Float64("uptime", 1.5),
+					slog.Bool("device.ready", true),
+					slog.String("device.interfaces.0.name", "erouter0"),
+				),
+			},
+		},
+		{
+			name:        "none_found",
+			paths:       []string{"missing"},
+			contentType: "application/json",
+			payload:     payload,
+		},
+		{
+			name:        "not_json",
+			paths:       []string{"status"},
+			contentType: "application/msgpack",
+			payload:     payload,
+		},
+		{
+			name:        "invalid",
+			paths:       []string{"status"},
+			contentType: "application/json",
+			payload:     []byte(`{"status":`),
+			expected:    []slog.Attr{slog.String(fPayloadError, "unexpected EOF")},
+		},
+		{
+			name:        "trailing_data",
+			paths:       []string{"status"},
+			contentType: "application/json",
+			payload:     []byte(`{"status":"online"} garbage`),
+			expected:    []slog.Attr{slog.String(fPayloadError, "invalid character 'g' looking for beginning of value")},
+		},
+		{
+			name:        "trailing_value",
+			paths:       []string{"status"},
+			contentType: "application/json",
+			payload:     []byte(`{"status":"online"} {}`),
+			expected:    []slog.Attr{slog.String(fPayloadError, "unexpected data after top-level value")},
+		},
+		{
+			name:        "trailing_space",
+			paths:       []string{"status"},
+			contentType: "application/json",
+			payload:     []byte("{\"status\":\"online\"}\n"),
+			expected:    []slog.Attr{slog.Group(fPayload, slog.String("status", "online"))},
+		},
+		{
+			name:        "too_large",
+			paths:       []string{"status"},
+			contentType: "application/json",
+			payload:     []byte(`{"status":"` + strings.Repeat("a", maxJSONBytes) + `"}`),
+			expected:    []slog.Attr{slog.String(fPayloadError, "payload of 65549 bytes is larger than 65536 bytes")},
+		},
+		{
+			name:        "empty",
+			paths:       []string{"status"},
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := wrp.Message{ContentType: tt.contentType, Payload: tt.payload}
+
+			got := observeOne(t, msg, PayloadJSONFields(tt.paths...))
+			require.Len(t, got, len(tt.expected))
+			for i, attr := range got {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestPayloadJSONFields_Disabled(t *testing.T) {
+	var decoded bool
+	ob := Observer{
+		Logger:  slog.New(disabledHandler{}),
+		Level:   slog.LevelInfo,
+		Message: "wrp message",
+		Fields: []FieldOpt{
+			PayloadJSONFields("status"),
+			Field("decoded", func(wrp.Message) slog.Attr {
+				decoded = true
+				return slog.Attr{}
+			}),
+		},
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		ContentType: "application/json",
+		Payload:     []byte(`{"status":"online"}`),
+	})
+
+	assert.False(t, decoded)
+}
+
+func TestPayload_Redacted(t *testing.T) {
+	payload := []byte(`{"source":"mac:112233445566","device":{"mac:665544332211":["uuid:abc"]}}`)
+
+	tests := []struct {
+		name     string
+		field    FieldOpt
+		expected slog.Attr
+	}{
+		{
+			name:     "preview",
+			field:    PayloadPreview(0),
+			expected: slog.String(fPayload, `{"source":"mac:************","device":{"mac:************":["uuid:***"]}}`),
+		}, {
+			name:  "json_fields",
+			field: PayloadJSONFields("source", "device"),
+			expected: slog.Group(fPayload,
+				slog.String("source", "mac:************"),
+				slog.Any("device", map[string]any{"mac:************": []any{"uuid:***"}}),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newRecordHandler(slog.LevelInfo)
+			ob := Observer{
+				Logger:   slog.New(handler),
+				Level:    slog.LevelInfo,
+				Message:  "wrp message",
+				Fields:   []FieldOpt{tt.field},
+				Redactor: MaskRedactor(),
+			}
+			ob.ObserveWRP(context.Background(), wrp.Message{ContentType: "application/json", Payload: payload})
+
+			require.Len(t, handler.records, 1)
+			attrs := handler.getAttrs(0)
+			require.Len(t, attrs, 1)
+			assert.Equal(t, tt.expected.String(), attrs[0].String())
+		})
+	}
+}
+
+func TestPayloadDigest(t *testing.T) {
+	payload := []byte(`{"status":"online"}`)
+
+	sum := sha256.Sum256(payload)
+	h := fnv.New64a()
+	h.Write(payload)
+
+	tests := []struct {
+		name     string
+		alg      DigestAlgorithm
+		payload  []byte
+		expected []slog.Attr
+	}{
+		{
+			name:     "sha256",
+			alg:      DigestSHA256,
+			payload:  payload,
+			expected: []slog.Attr{slog.String(fPayloadDigest, hex.EncodeToString(sum[:]))},
+		},
+		{
+			name:     "fnv1a",
+			alg:      DigestFNV1a,
+			payload:  payload,
+			expected: []slog.Attr{slog.String(fPayloadDigest, hex.EncodeToString(h.Sum(nil)))},
+		},
+		{
+			name:    "unknown",
+			alg:     DigestAlgorithm(-1),
+			payload: payload,
+		},
+		{
+			name: "empty",
+			alg:  DigestSHA256,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := observeOne(t, wrp.Message{Payload: tt.payload}, PayloadDigest(tt.alg))
+			require.Len(t, got, len(tt.expected))
+			for i, attr := range got {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
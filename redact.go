@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Redactor replaces a device identifier before it is logged. scheme is the
+// lowercased locator scheme (mac, uuid or serial) and id is the normalized
+// identifier without the scheme, e.g. 112233445566. The returned string
+// replaces id; the scheme is kept so the kind of device is still visible.
+//
+// A Redactor must be safe for concurrent use.
+type Redactor func(scheme, id string) string
+
+// MaskRedactor returns a Redactor that replaces every character of the device
+// identifier with '*'.
+func MaskRedactor() Redactor {
+	return func(_, id string) string {
+		return strings.Repeat("*", len(id))
+	}
+}
+
+// HMACRedactor returns a Redactor that replaces the device identifier with
+// the first 16 hex characters of its HMAC-SHA256 under key. The same device
+// always yields the same value for a given key, so log lines can still be
+// correlated by device without revealing the identifier.
+func HMACRedactor(key []byte) Redactor {
+	key = bytes.Clone(key)
+	return func(scheme, id string) string {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(scheme))
+		h.Write([]byte{':'})
+		h.Write([]byte(id))
+
+		var sum [sha256.Size]byte
+		return hex.EncodeToString(h.Sum(sum[:0])[:8])
+	}
+}
+
+// isRedactedScheme reports whether scheme carries a device identifier that
+// is passed to the Redactor.
+func isRedactedScheme(scheme string) bool {
+	switch scheme {
+	case schemeMAC, schemeUUID, schemeSerial:
+		return true
+	default:
+		return false
+	}
+}
+
+// redactDeviceIDs replaces every device identifier in s, such as the
+// mac:112233445566 in event:device-status/mac:112233445566/online, with the
+// value returned by redact. s is returned unchanged if redact is nil or no
+// identifier is found.
+func redactDeviceIDs(s string, redact Redactor) string {
+	if redact == nil || s == "" {
+		return s
+	}
+
+	var b strings.Builder
+	var last int
+	for i := 0; i < len(s); {
+		scheme, n := deviceSchemeAt(s, i)
+		if n == 0 {
+			i++
+			continue
+		}
+
+		start := i + n
+		end := start
+		for end < len(s) && !isIDTerminator(s[end]) {
+			end++
+		}
+		if end == start {
+			i = start
+			continue
+		}
+
+		b.WriteString(s[last:i])
+		b.WriteString(scheme)
+		b.WriteByte(':')
+		b.WriteString(redact(scheme, deviceAuthority(scheme, s[start:end])))
+		last, i = end, end
+	}
+
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// deviceSchemeAt returns the lowercased redacted scheme starting at s[i] and
+// the length of the scheme including its ':'. The scheme must start a token;
+// n is 0 if there is no match.
+func deviceSchemeAt(s string, i int) (string, int) {
+	if i > 0 && isAlphaNum(s[i-1]) {
+		return "", 0
+	}
+
+	for _, candidate := range [...]string{schemeMAC, schemeUUID, schemeSerial} {
+		end := i + len(candidate)
+		if end < len(s) && s[end] == ':' && strings.EqualFold(s[i:end], candidate) {
+			return candidate, len(candidate) + 1
+		}
+	}
+	return "", 0
+}
+
+func isAlphaNum(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// isIDTerminator reports whether c ends a device identifier embedded in a
+// larger string.
+func isIDTerminator(c byte) bool {
+	switch c {
+	case '/', ' ', '\t', '\n', '"', '\'', ',', ';', '?', '&', '#':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestRedactDeviceIDs(t *testing.T) {
+	redact := func(scheme, id string) string {
+		return "<" + scheme + "-" + id + ">"
+	}
+
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{
+			name:     "locator",
+			in:       "mac:112233445566/config",
+			expected: "mac:<mac-112233445566>/config",
+		},
+		{
+			name:     "normalized",
+			in:       "MAC:11:22:33:AA:BB:CC",
+			expected: "mac:<mac-112233aabbcc>",
+		},
+		{
+			name:     "embedded",
+			in:       "event:device-status/uuid:ABC/online",
+			expected: "event:device-status/uuid:<uuid-abc>/online",
+		},
+		{
+			name:     "multiple",
+			in:       "serial:A1 and mac:112233445566",
+			expected: "serial:<serial-A1> and mac:<mac-112233445566>",
+		},
+		{
+			name:     "not_a_token",
+			in:       "dns:mac.example.com/hmac:123",
+			expected: "dns:mac.example.com/hmac:123",
+		},
+		{
+			name:     "no_id",
+			in:       "mac:/config",
+			expected: "mac:/config",
+		},
+		{
+			name:     "empty",
+			in:       "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, redactDeviceIDs(tt.in, redact))
+			assert.Equal(t, tt.in, redactDeviceIDs(tt.in, nil))
+		})
+	}
+}
+
+func TestHMACRedactor(t *testing.T) {
+	redact := HMACRedactor([]byte("secret"))
+
+	id := redact(schemeMAC, "112233445566")
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, redact(schemeMAC, "112233445566"))
+	assert.NotEqual(t, id, redact(schemeMAC, "112233445567"))
+	assert.NotEqual(t, id, redact(schemeSerial, "112233445566"))
+	assert.NotEqual(t, id, HMACRedactor([]byte("other"))(schemeMAC, "112233445566"))
+}
+
+func TestMaskRedactor(t *testing.T) {
+	assert.Equal(t, "************", MaskRedactor()(schemeMAC, "112233445566"))
+}
+
+func TestObserver_Redactor(t *testing.T) {
+	var buf bytes.Buffer
+	ob := Observer{
+		Logger:   slog.New(slog.NewJSONHandler(&buf, nil)),
+		Level:    slog.LevelInfo,
+		Message:  "wrp message",
+		Redactor: HMACRedactor([]byte("secret")),
+		Fields: []FieldOpt{
+			SourceLocator(),
+			Destination(),
+			DeviceID(),
+			EventSubpath(),
+			Headers(),
+			Metadata(),
+			URL(),
+		},
+	}
+
+	ob.ObserveWRP(context.Background(), wrp.Message{
+		Source:      "mac:11:22:33:44:55:66/config",
+		Destination: "event:device-status/mac:112233445566/online",
+		Headers:     []string{"X-Device: mac:112233445566", "mac:112233445566"},
+		Metadata:    map[string]string{"/last-reconnect-reason": "mac:112233445566 rebooted"},
+		URL:         "http://talaria.example.com/api/v2/device/mac:112233445566/stat",
+	})
+
+	hashed := HMACRedactor([]byte("secret"))(schemeMAC, "112233445566")
+	out := buf.String()
+	assert.NotContains(t, out, "112233445566")
+	assert.NotContains(t, out, "11:22:33:44:55:66")
+	assert.Contains(t, out, `"authority":"`+hashed+`"`)
+	assert.Contains(t, out, `"dest":"event:device-status/mac:`+hashed+`/online"`)
+	assert.Contains(t, out, `"device_id":"mac:`+hashed+`"`)
+	assert.Contains(t, out, `"event_subpath":"mac:`+hashed+`/online"`)
+	assert.Contains(t, out, `"headers":{"X-Device":"mac:`+hashed+`","mac":"`+hashed+`"}`)
+	assert.Contains(t, out, `"/last-reconnect-reason":"mac:`+hashed+` rebooted"`)
+	assert.Contains(t, out, `"url":"http://talaria.example.com/api/v2/device/mac:`+hashed+`/stat"`)
+}
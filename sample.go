@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+const fSampleRate = "sample_rate"
+
+// Sampler decides whether a message is logged. It returns whether msg is
+// sampled and the sample rate: the number of messages each logged message
+// stands for. The rate is logged as sample_rate so that counts can be
+// re-weighted downstream.
+//
+// A Sampler is called for every message that passes the level check, before
+// any field is extracted, so it must be cheap and safe for concurrent use.
+type Sampler func(ctx context.Context, msg wrp.Message) (rate float64, ok bool)
+
+// FixedRate returns a Sampler that logs each message with probability
+// fraction, independently of other messages. A fraction of 1 or more logs
+// every message and a fraction of 0 or less logs none.
+func FixedRate(fraction float64) Sampler {
+	switch {
+	case fraction >= 1:
+		return func(context.Context, wrp.Message) (float64, bool) {
+			return 1, true
+		}
+	case fraction <= 0:
+		return func(context.Context, wrp.Message) (float64, bool) {
+			return 0, false
+		}
+	}
+
+	rate := 1 / fraction
+	return func(context.Context, wrp.Message) (float64, bool) {
+		return rate, rand.Float64() < fraction
+	}
+}
+
+// OneInN returns a Sampler that logs on average one message in n. An n of 1
+// or less logs every message.
+func OneInN(n int) Sampler {
+	if n <= 1 {
+		return FixedRate(1)
+	}
+	return FixedRate(1 / float64(n))
+}
+
+// SampleBy selects what a ConsistentSampler samples by.
+type SampleBy int
+
+const (
+	// SampleByTransaction samples by TransactionUUID, so a request and its
+	// response are either both logged or both dropped. Messages without a
+	// TransactionUUID are sampled by device.
+	SampleByTransaction SampleBy = iota
+
+	// SampleByDevice samples by the device identified by the Source or
+	// Destination, the same device logged by DeviceID, so the timeline of a
+	// sampled device is complete.
+	SampleByDevice
+)
+
+// ConsistentSampler returns a Sampler that logs the fraction of transactions
+// or devices selected by by. Whether a transaction or device is sampled
+// depends only on its identifier and seed, so every process using the same
+// seed and fraction logs the same ones. Messages that carry no identifier are
+// sampled at random, as by FixedRate.
+func ConsistentSampler(fraction float64, seed uint64, by SampleBy) Sampler {
+	if fraction >= 1 || fraction <= 0 {
+		return FixedRate(fraction)
+	}
+
+	rate := 1 / fraction
+	threshold := uint64(math.Ldexp(fraction, 64))
+	fallback := FixedRate(fraction)
+	return func(ctx context.Context, msg wrp.Message) (float64, bool) {
+		if by == SampleByTransaction && msg.TransactionUUID != "" {
+			return rate, sampleHash(seed, msg.TransactionUUID, "") < threshold
+		}
+		if scheme, authority := messageDevice(msg); scheme != "" {
+			return rate, sampleHash(seed, scheme, authority) < threshold
+		}
+		return fallback(ctx, msg)
+	}
+}
+
+// separator ends the strings hashed by sampleHash.
+var separator = [1]byte{0xff}
+
+// sampleHash returns a well mixed hash of seed, a and b. It is the 64-bit
+// FNV-1a hash of the little endian seed and the strings, each followed by a
+// 0xff separator so that ("ab", "c") and ("a", "bc") differ, passed through
+// the SplitMix64 finalizer so that its high bits are evenly distributed.
+func sampleHash(seed uint64, a, b string) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], seed)
+
+	f := fnv.New64a()
+	f.Write(buf[:])
+	f.Write([]byte(a))
+	f.Write(separator[:])
+	f.Write([]byte(b))
+	f.Write(separator[:])
+	h := f.Sum64()
+
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestFixedRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampler  Sampler
+		rate     float64
+		min, max int
+	}{
+		{name: "all", sampler: FixedRate(1), rate: 1, min: 1000, max: 1000},
+		{name: "above_one", sampler: FixedRate(2), rate: 1, min: 1000, max: 1000},
+		{name: "none", sampler: FixedRate(0), rate: 0, min: 0, max: 0},
+		{name: "negative", sampler: FixedRate(-1), rate: 0, min: 0, max: 0},
+		{name: "quarter", sampler: FixedRate(0.25), rate: 4, min: 150, max: 350},
+		{name: "one_in_ten", sampler: OneInN(10), rate: 10, min: 40, max: 160},
+		{name: "one_in_one", sampler: OneInN(1), rate: 1, min: 1000, max: 1000},
+		{name: "one_in_zero", sampler: OneInN(0), rate: 1, min: 1000, max: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count int
+			for range 1000 {
+				rate, ok := tt.sampler(context.Background(), wrp.Message{})
+				assert.Equal(t, tt.rate, rate)
+				if ok {
+					count++
+				}
+			}
+			assert.GreaterOrEqual(t, count, tt.min)
+			assert.LessOrEqual(t, count, tt.max)
+		})
+	}
+}
+
+func TestObserver_Sampler(t *testing.T) {
+	var extracted int
+	counting := Field("counted", func(msg wrp.Message) slog.Attr {
+		extracted++
+		return slog.String("", msg.Source)
+	})
+
+	handler := newRecordHandler(slog.LevelInfo)
+	ob := Observer{
+		Logger:    slog.New(handler),
+		Level:     slog.LevelInfo,
+		Message:   "wrp message",
+		Fields:    []FieldOpt{Source(), counting},
+		KeyPrefix: "wrp_",
+	}
+
+	msg := wrp.Message{Source: "dns:example.com"}
+
+	ob.Sampler = func(context.Context, wrp.Message) (float64, bool) { return 8, false }
+	ob.ObserveWRP(context.Background(), msg)
+	assert.Empty(t, handler.records)
+	assert.Zero(t, extracted, "fields are not extracted for dropped messages")
+
+	ob.Sampler = func(context.Context, wrp.Message) (float64, bool) { return 8, true }
+	ob.ObserveWRP(context.Background(), msg)
+	require.Len(t, handler.records, 1)
+	attrs := handler.getAttrs(0)
+	require.Len(t, attrs, 3)
+	assert.True(t, slog.Float64("wrp_sample_rate", 8).Equal(attrs[2]))
+
+	ob.Sampler = nil
+	ob.ObserveWRP(context.Background(), msg)
+	require.Len(t, handler.records, 2)
+	assert.Len(t, handler.getAttrs(1), 2, "no sample rate without a sampler")
+}
+
+func TestConsistentSampler(t *testing.T) {
+	const fraction = 0.25
+
+	t.Run("fraction", func(t *testing.T) {
+		sampler := ConsistentSampler(fraction, 1, SampleByTransaction)
+		var count int
+		for i := range 2000 {
+			rate, ok := sampler(context.Background(), wrp.Message{TransactionUUID: fmt.Sprintf("txn-%d", i)})
+			assert.Equal(t, 4.0, rate)
+			if ok {
+				count++
+			}
+		}
+		assert.Greater(t, count, 350)
+		assert.Less(t, count, 650)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		// Two samplers stand in for two processes sharing a seed.
+		a := ConsistentSampler(fraction, 7, SampleByTransaction)
+		b := ConsistentSampler(fraction, 7, SampleByTransaction)
+		for i := range 200 {
+			uuid := fmt.Sprintf("txn-%d", i)
+			request := wrp.Message{Source: "dns:scytale.example.com", Destination: "mac:112233445566", TransactionUUID: uuid}
+			response := wrp.Message{Source: "mac:112233445566", Destination: "dns:scytale.example.com", TransactionUUID: uuid}
+
+			_, sampled := a(context.Background(), request)
+			_, again := a(context.Background(), request)
+			_, other := b(context.Background(), response)
+			assert.Equal(t, sampled, again)
+			assert.Equal(t, sampled, other, "a request and its response are sampled together")
+		}
+	})
+
+	t.Run("device", func(t *testing.T) {
+		sampler := ConsistentSampler(fraction, 7, SampleByDevice)
+		for i := range 200 {
+			mac := fmt.Sprintf("%012x", i)
+			up := wrp.Message{Source: "mac:" + mac + "/config", Destination: "event:device-status", TransactionUUID: "a"}
+			down := wrp.Message{Source: "dns:talaria.example.com", Destination: "MAC:" + mac, TransactionUUID: "b"}
+
+			_, sampled := sampler(context.Background(), up)
+			_, other := sampler(context.Background(), down)
+			assert.Equal(t, sampled, other, "every message of a device is sampled together")
+		}
+	})
+
+	t.Run("transaction_falls_back_to_device", func(t *testing.T) {
+		byTransaction := ConsistentSampler(fraction, 7, SampleByTransaction)
+		byDevice := ConsistentSampler(fraction, 7, SampleByDevice)
+		for i := range 200 {
+			msg := wrp.Message{Source: fmt.Sprintf("mac:%012x", i)}
+			_, a := byTransaction(context.Background(), msg)
+			_, b := byDevice(context.Background(), msg)
+			assert.Equal(t, a, b)
+		}
+	})
+
+	t.Run("seed", func(t *testing.T) {
+		a := ConsistentSampler(fraction, 1, SampleByTransaction)
+		b := ConsistentSampler(fraction, 2, SampleByTransaction)
+		var differ int
+		for i := range 200 {
+			msg := wrp.Message{TransactionUUID: fmt.Sprintf("txn-%d", i)}
+			_, x := a(context.Background(), msg)
+			_, y := b(context.Background(), msg)
+			if x != y {
+				differ++
+			}
+		}
+		assert.Positive(t, differ)
+	})
+
+	t.Run("no_identifier", func(t *testing.T) {
+		sampler := ConsistentSampler(fraction, 7, SampleByDevice)
+		var count int
+		for range 2000 {
+			if _, ok := sampler(context.Background(), wrp.Message{Source: "event:device-status"}); ok {
+				count++
+			}
+		}
+		assert.Greater(t, count, 350)
+		assert.Less(t, count, 650)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		msg := wrp.Message{TransactionUUID: "txn"}
+		_, ok := ConsistentSampler(1, 7, SampleByTransaction)(context.Background(), msg)
+		assert.True(t, ok)
+		_, ok = ConsistentSampler(0, 7, SampleByTransaction)(context.Background(), msg)
+		assert.False(t, ok)
+	})
+}
+
+// TestSampleHash pins the hash so that processes running different versions
+// of the package keep sampling the same transactions and devices.
+func TestSampleHash(t *testing.T) {
+	assert.Equal(t, uint64(0xfb115f2cc3c4c027), sampleHash(0, "", ""))
+	assert.Equal(t, uint64(0xf709e97ab42a0be3), sampleHash(42, "546514d4-9cb6-41c9-88ca-ccd4c130c525", ""))
+	assert.Equal(t, uint64(0xc1c42d0cdef68344), sampleHash(42, "mac", "112233445566"))
+	assert.NotEqual(t, sampleHash(42, "ab", "c"), sampleHash(42, "a", "bc"))
+}
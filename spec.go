@@ -0,0 +1,298 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownField is returned by ParseFields for a field name it does not
+	// recognize.
+	ErrUnknownField = errors.New("unknown field")
+
+	// ErrInvalidFieldSpec is returned by ParseFields for a malformed spec or a
+	// field given a variant or arguments it does not accept.
+	ErrInvalidFieldSpec = errors.New("invalid field spec")
+)
+
+// fieldBuilder creates the FieldOpt for one entry of a field spec.
+type fieldBuilder func(variant string, args []string, always bool) (FieldOpt, error)
+
+// fieldBuilders maps the names accepted by ParseFields to their builders.
+var fieldBuilders = map[string]fieldBuilder{
+	fMsgType:                 buildMsgType,
+	fSource:                  locatorField(Source, SourceAlways, SourceLocator),
+	fDestination:             locatorField(Destination, DestinationAlways, DestinationLocator),
+	fTransactionUUID:         simpleField(TransactionUUID, TransactionUUIDAlways),
+	fContentType:             simpleField(ContentType, ContentTypeAlways),
+	fAccept:                  simpleField(Accept, AcceptAlways),
+	fStatus:                  simpleField(Status, StatusAlways),
+	fRequestDeliveryResponse: simpleField(RequestDeliveryResponse, RequestDeliveryResponseAlways),
+	fHeaders:                 simpleField(Headers, HeadersAlways),
+	fMetadata:                buildMetadata,
+	fPath:                    simpleField(Path, PathAlways),
+	fPayload:                 buildPayload,
+	fPayloadSize:             simpleField(PayloadSize, PayloadSizeAlways),
+	fServiceName:             simpleField(ServiceName, ServiceNameAlways),
+	fURL:                     simpleField(URL, URLAlways),
+	fPartnerIDs:              simpleField(PartnerIDs, PartnerIDsAlways),
+	fSessionID:               simpleField(SessionID, SessionIDAlways),
+	fQualityOfService:        simpleField(QualityOfService, QualityOfServiceAlways),
+	fDeviceID:                simpleField(DeviceID, DeviceIDAlways),
+	fEventType:               simpleField(EventType, nil),
+	fEventSubpath:            simpleField(EventSubpath, nil),
+	fPayloadDigest:           buildPayloadDigest,
+}
+
+// ParseFields parses a comma separated field spec, such as
+//
+//	msg_type:string,source,dest!,payload_size,metadata[hw-model,fw-name]
+//
+// into field options. Each entry is a field name, using the same names the
+// fields are logged under, optionally followed by a :variant, a bracketed
+// argument list and a trailing ! to log the field even when empty.
+//
+// The supported entries are:
+//
+//   - msg_type, msg_type:num, msg_type:string
+//   - source, dest: also source:locator and dest:locator
+//   - transaction_uuid, content_type, accept, status, rdr, headers, path,
+//     payload_size, service_name, url, partner_ids, session_id, qos, device_id
+//   - event_type, event_subpath
+//   - metadata, metadata[key,...] and metadata:except[key,...]
+//   - payload (base64), payload:preview[maxBytes], payload:json[path,...] and
+//     payload:msgpack[maxDepth,maxEntries,maxBytes]
+//   - payload_digest, payload_digest:sha256, payload_digest:fnv1a
+//
+// Errors wrap ErrUnknownField or ErrInvalidFieldSpec.
+func ParseFields(spec string) ([]FieldOpt, error) {
+	entries, err := splitSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]FieldOpt, 0, len(entries))
+	for _, entry := range entries {
+		opt, err := parseField(entry)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
+
+// splitSpec splits spec on the commas that are not inside brackets. Empty
+// entries are dropped.
+func splitSpec(spec string) ([]string, error) {
+	var entries []string
+	var depth, start int
+	for i := 0; i < len(spec); i++ {
+		switch spec[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unexpected ']' in %q", ErrInvalidFieldSpec, spec)
+			}
+		case ',':
+			if depth == 0 {
+				entries = appendEntry(entries, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unclosed '[' in %q", ErrInvalidFieldSpec, spec)
+	}
+	return appendEntry(entries, spec[start:]), nil
+}
+
+// appendEntry appends the trimmed entry unless it is empty.
+func appendEntry(entries []string, entry string) []string {
+	if entry = strings.TrimSpace(entry); entry != "" {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// parseField parses a single spec entry.
+func parseField(entry string) (FieldOpt, error) {
+	rest, always := strings.CutSuffix(entry, "!")
+
+	var args []string
+	if i := strings.IndexByte(rest, '['); i >= 0 {
+		if !strings.HasSuffix(rest, "]") {
+			return nil, fmt.Errorf("%w: %q: arguments must end the entry", ErrInvalidFieldSpec, entry)
+		}
+		for _, arg := range strings.Split(rest[i+1:len(rest)-1], ",") {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				args = append(args, arg)
+			}
+		}
+		rest = rest[:i]
+	}
+
+	name, variant, _ := strings.Cut(rest, ":")
+	name, variant = strings.TrimSpace(name), strings.TrimSpace(variant)
+
+	build, found := fieldBuilders[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownField, name)
+	}
+
+	opt, err := build(variant, args, always)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidFieldSpec, entry, err)
+	}
+	return opt, nil
+}
+
+// simpleField builds fields that take no variant or arguments. A nil
+// alwaysOpt means the field has no always form.
+func simpleField(opt, alwaysOpt func() FieldOpt) fieldBuilder {
+	return func(variant string, args []string, always bool) (FieldOpt, error) {
+		if err := noArgs(variant, args); err != nil {
+			return nil, err
+		}
+		if !always {
+			return opt(), nil
+		}
+		if alwaysOpt == nil {
+			return nil, errors.New("'!' is not supported")
+		}
+		return alwaysOpt(), nil
+	}
+}
+
+// locatorField builds the source and destination fields.
+func locatorField(opt, alwaysOpt, locatorOpt func() FieldOpt) fieldBuilder {
+	return func(variant string, args []string, always bool) (FieldOpt, error) {
+		if variant != "locator" {
+			return simpleField(opt, alwaysOpt)(variant, args, always)
+		}
+		return simpleField(locatorOpt, nil)("", args, always)
+	}
+}
+
+func buildMsgType(variant string, args []string, _ bool) (FieldOpt, error) {
+	if len(args) > 0 {
+		return nil, errors.New("arguments are not supported")
+	}
+
+	switch variant {
+	case "", "num":
+		return MessageTypeAsNum(), nil
+	case "string":
+		return MessageTypeAsString(), nil
+	default:
+		return nil, fmt.Errorf("unknown variant %q", variant)
+	}
+}
+
+func buildMetadata(variant string, args []string, always bool) (FieldOpt, error) {
+	switch variant {
+	case "":
+		if len(args) == 0 {
+			return simpleField(Metadata, MetadataAlways)("", nil, always)
+		}
+		if always {
+			return nil, errors.New("'!' is not supported with keys")
+		}
+		return MetadataKeys(args...), nil
+	case "except":
+		if always {
+			return nil, errors.New("'!' is not supported with except")
+		}
+		return MetadataExcept(args...), nil
+	default:
+		return nil, fmt.Errorf("unknown variant %q", variant)
+	}
+}
+
+func buildPayload(variant string, args []string, always bool) (FieldOpt, error) {
+	if always && variant != "" && variant != "base64" {
+		return nil, fmt.Errorf("'!' is not supported with %s", variant)
+	}
+
+	switch variant {
+	case "", "base64":
+		return simpleField(PayloadAsBase64, PayloadAsBase64Always)("", args, always)
+	case "preview":
+		if len(args) != 1 {
+			return nil, errors.New("the maximum number of bytes is required")
+		}
+		n, err := intArgs(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return PayloadPreview(n[0]), nil
+	case "json":
+		if len(args) == 0 {
+			return nil, errors.New("at least one path is required")
+		}
+		return PayloadJSONFields(args...), nil
+	case "msgpack":
+		n, err := intArgs(args, 3)
+		if err != nil {
+			return nil, err
+		}
+		return PayloadMsgpack(n[0], n[1], n[2]), nil
+	default:
+		return nil, fmt.Errorf("unknown variant %q", variant)
+	}
+}
+
+func buildPayloadDigest(variant string, args []string, always bool) (FieldOpt, error) {
+	if err := noArgs("", args); err != nil {
+		return nil, err
+	}
+	if always {
+		return nil, errors.New("'!' is not supported")
+	}
+
+	switch variant {
+	case "", "sha256":
+		return PayloadDigest(DigestSHA256), nil
+	case "fnv1a":
+		return PayloadDigest(DigestFNV1a), nil
+	default:
+		return nil, fmt.Errorf("unknown variant %q", variant)
+	}
+}
+
+// noArgs returns an error if a variant or arguments were given.
+func noArgs(variant string, args []string) error {
+	if variant != "" {
+		return fmt.Errorf("unknown variant %q", variant)
+	}
+	if len(args) > 0 {
+		return errors.New("arguments are not supported")
+	}
+	return nil
+}
+
+// intArgs parses up to count integer arguments. Missing arguments are zero,
+// which selects the field's default.
+func intArgs(args []string, count int) ([]int, error) {
+	if len(args) > count {
+		return nil, fmt.Errorf("at most %d arguments are supported", count)
+	}
+
+	n := make([]int, count)
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q is not an integer", arg)
+		}
+		n[i] = v
+	}
+	return n, nil
+}
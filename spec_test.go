@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package wrpslog
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v5"
+)
+
+func TestParseFields(t *testing.T) {
+	status := int64(200)
+	msg := wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "mac:112233445566/config",
+		ContentType: "application/json",
+		Status:      &status,
+		Metadata:    map[string]string{"hw-model": "XB7", "fw-name": "fw-1", "secret": "s"},
+		Payload:     []byte(`{"status":"online"}`),
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected []slog.Attr
+	}{
+		{
+			name: "example",
+			spec: "msg_type:string,source,dest!,payload_size,metadata[hw-model,fw-name]",
+			expected: []slog.Attr{
+				slog.String(fMsgType, wrp.SimpleEventMessageType.String()),
+				slog.String(fSource, "mac:112233445566/config"),
+				slog.String(fDestination, ""),
+				slog.Group(fMetadata, slog.String("fw-name", "fw-1"), slog.String("hw-model", "XB7")),
+				slog.Int(fPayloadSize, len(msg.Payload)),
+			},
+		},
+		{
+			name: "variants",
+			spec: " msg_type:num , source:locator, status!, device_id, metadata:except[secret, fw-name], payload:json[status] ",
+			expected: []slog.Attr{
+				slog.Int(fMsgType, int(wrp.SimpleEventMessageType)),
+				slog.Group(fSource,
+					slog.String(fScheme, "mac"),
+					slog.String(fAuthority, "112233445566"),
+					slog.String(fService, "config"),
+				),
+				slog.Int64(fStatus, 200),
+				slog.Group(fMetadata, slog.String("hw-model", "XB7")),
+				slog.Group(fPayload, slog.String("status", "online")),
+				slog.String(fDeviceID, "mac:112233445566"),
+			},
+		},
+		{
+			name: "payload_preview",
+			spec: "payload:preview[4]",
+			expected: []slog.Attr{
+				slog.String(fPayload, `{"st`),
+				slog.Bool(fPayloadTruncated, true),
+			},
+		},
+		{
+			name:     "payload_preview_default",
+			spec:     "payload:preview[0]",
+			expected: []slog.Attr{slog.String(fPayload, `{"status":"online"}`)},
+		},
+		{
+			name:     "empty",
+			spec:     " , ",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := ParseFields(tt.spec)
+			require.NoError(t, err)
+
+			attrs := observeOne(t, msg, fields...)
+			require.Len(t, attrs, len(tt.expected))
+			for i, attr := range attrs {
+				assert.True(t, tt.expected[i].Equal(attr), "expected %v, got %v", tt.expected[i], attr)
+			}
+		})
+	}
+}
+
+func TestParseFields_AllNames(t *testing.T) {
+	for name := range fieldBuilders {
+		t.Run(name, func(t *testing.T) {
+			fields, err := ParseFields(name + "," + name + "!")
+			if err != nil {
+				// Not every field has an always form.
+				fields, err = ParseFields(name)
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, fields)
+		})
+	}
+}
+
+func TestParseFields_Errors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected error
+	}{
+		{spec: "source,bogus", expected: ErrUnknownField},
+		{spec: "Source", expected: ErrUnknownField},
+		{spec: "source:bogus", expected: ErrInvalidFieldSpec},
+		{spec: "source[a]", expected: ErrInvalidFieldSpec},
+		{spec: "source:locator!", expected: ErrInvalidFieldSpec},
+		{spec: "event_type!", expected: ErrInvalidFieldSpec},
+		{spec: "msg_type:hex", expected: ErrInvalidFieldSpec},
+		{spec: "metadata[a]!", expected: ErrInvalidFieldSpec},
+		{spec: "metadata:bogus[a]", expected: ErrInvalidFieldSpec},
+		{spec: "metadata[a", expected: ErrInvalidFieldSpec},
+		{spec: "metadata]a[", expected: ErrInvalidFieldSpec},
+		{spec: "metadata[a]x", expected: ErrInvalidFieldSpec},
+		{spec: "payload:preview", expected: ErrInvalidFieldSpec},
+		{spec: "payload:preview[ten]", expected: ErrInvalidFieldSpec},
+		{spec: "payload:msgpack[1,2,3,4]", expected: ErrInvalidFieldSpec},
+		{spec: "payload:json", expected: ErrInvalidFieldSpec},
+		{spec: "payload:json[a]!", expected: ErrInvalidFieldSpec},
+		{spec: "payload_digest:md5", expected: ErrInvalidFieldSpec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			fields, err := ParseFields(tt.spec)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Nil(t, fields)
+		})
+	}
+}